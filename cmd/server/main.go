@@ -12,11 +12,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
-	router "github.com/dkeye/Voice/internal/adapters/http"
-	"github.com/dkeye/Voice/internal/app"
-	"github.com/dkeye/Voice/internal/app/orch"
-	"github.com/dkeye/Voice/internal/app/sfu"
-	"github.com/dkeye/Voice/internal/config"
+	"github.com/dkeye/Voice/pkg/voice"
 )
 
 func main() {
@@ -29,21 +25,15 @@ func main() {
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
 
-	cfg, err := config.Load()
+	cfg, err := voice.LoadConfig()
 	if err != nil {
 		log.Error().Err(err).Msg("failed to load config")
 	}
 
-	// Properly wire orchestrator with room manager and policy.
-	manager := app.NewRoomManager()
-	// policy := app.SimplePolicy{}
-	var policy app.Policy
-	reg := app.NewRegistry()
-	relays := sfu.NewRelayManager()
-
-	orch := orch.NewOrchestrator(reg, manager, policy, relays)
-
-	r := router.SetupRouter(ctx, cfg, orch)
+	r, err := voice.NewServer(ctx, cfg, voice.DefaultHandlers())
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to set up server")
+	}
 	addr := fmt.Sprintf(":%d", cfg.Port)
 
 	srv := &http.Server{
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dkeye/Voice/internal/app"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// sessionAccount is the session key holding the logged-in login.
+const sessionAccount = "account"
+
+type credentials struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+type accountResponse struct {
+	Login string      `json:"login"`
+	User  domain.User `json:"user"`
+}
+
+func registerAuthRoutes(api *gin.RouterGroup, reg *app.Registry) {
+	api.POST("/register", func(c *gin.Context) {
+		var cr credentials
+		if err := c.ShouldBindJSON(&cr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		p, err := reg.Register(cr.Login, cr.Password)
+		if err != nil {
+			authFail(c, err)
+			return
+		}
+		startSession(c, p, http.StatusCreated)
+	})
+
+	api.POST("/login", func(c *gin.Context) {
+		var cr credentials
+		if err := c.ShouldBindJSON(&cr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		p, err := reg.Login(cr.Login, cr.Password)
+		if err != nil {
+			authFail(c, err)
+			return
+		}
+		startSession(c, p, http.StatusOK)
+	})
+
+	api.POST("/logout", func(c *gin.Context) {
+		s := sessions.Default(c)
+		s.Delete(sessionAccount)
+		if err := s.Save(); err != nil {
+			log.Error().Err(err).Str("module", "adapters.http").Msg("save session")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "session unavailable"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+}
+
+func startSession(c *gin.Context, p domain.UserProfile, status int) {
+	s := sessions.Default(c)
+	s.Set(sessionAccount, p.Login)
+	if err := s.Save(); err != nil {
+		log.Error().Err(err).Str("module", "adapters.http").Msg("save session")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "session unavailable"})
+		return
+	}
+	c.JSON(status, accountResponse{Login: p.Login, User: domain.User{ID: p.ID, Username: p.Username}})
+}
+
+func authFail(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, app.ErrBadLogin), errors.Is(err, app.ErrWeakPassword):
+		status = http.StatusBadRequest
+	case errors.Is(err, app.ErrBadCredentials):
+		status = http.StatusUnauthorized
+	case errors.Is(err, app.ErrAccountExists):
+		status = http.StatusConflict
+	case errors.Is(err, app.ErrNoAccounts):
+		status = http.StatusNotImplemented
+	default:
+		log.Error().Err(err).Str("module", "adapters.http").Msg("account")
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
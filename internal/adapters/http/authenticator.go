@@ -0,0 +1,78 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dkeye/Voice/internal/app"
+	"github.com/dkeye/Voice/internal/app/orch"
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrLoginRequired is returned for a guest when guests are not allowed.
+var ErrLoginRequired = errors.New("login required")
+
+// Authenticator decides who a request is from. It runs in front of the
+// signal socket and the REST API; an error answers the request with 401.
+type Authenticator interface {
+	Authenticate(c *gin.Context) (core.Identity, error)
+}
+
+// clientCookieAge is how long the ct cookie lives when no MaxAge is set.
+const clientCookieAge = 7 * 24 * time.Hour
+
+// CookieAuthenticator identifies clients by the ct cookie, issuing one to
+// newcomers, and by the logged-in account of the session if there is one.
+type CookieAuthenticator struct {
+	// AllowGuests admits clients that have not logged in.
+	AllowGuests bool
+	// MaxAge is how long the ct cookie lives after the last request; the
+	// guest's stored profile is keyed by it, so it should be at least the
+	// guest TTL. Zero means a week.
+	MaxAge time.Duration
+}
+
+func (a CookieAuthenticator) Authenticate(c *gin.Context) (core.Identity, error) {
+	if login, ok := sessions.Default(c).Get(sessionAccount).(string); ok && login != "" {
+		c.Set("account", login)
+		return core.Identity{Client: app.AccountClient(login)}, nil
+	}
+	if !a.AllowGuests {
+		return core.Identity{}, ErrLoginRequired
+	}
+	token, _ := c.Cookie("ct")
+	if token == "" {
+		token = genClientToken()
+	}
+	// Every request renews the cookie, so a guest who keeps coming back
+	// keeps its name and preferences.
+	maxAge := a.MaxAge
+	if maxAge <= 0 {
+		maxAge = clientCookieAge
+	}
+	c.SetCookie("ct", token, int(maxAge/time.Second), "/", "", false, true)
+	return core.Identity{Client: core.ClientID(token)}, nil
+}
+
+// Authenticate runs auth and records the outcome on the context: the
+// identity under "identity" and its client under "client_token".
+func Authenticate(auth Authenticator, o *orch.Orchestrator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := auth.Authenticate(c)
+		if err == nil {
+			err = o.Identify(id)
+		}
+		if err != nil {
+			log.Info().Err(err).Str("module", "adapters.http").Str("path", c.FullPath()).Msg("unauthenticated")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set("identity", id)
+		c.Set("client_token", string(id.Client))
+		c.Next()
+	}
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+)
+
+// guestCookie runs one request through auth and returns the ct cookie it set.
+func guestCookie(t *testing.T, auth CookieAuthenticator, sent *http.Cookie) *http.Cookie {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(sessions.Sessions("VoiceSessions", cookie.NewStore([]byte("secret"))))
+	r.GET("/", func(c *gin.Context) {
+		if _, err := auth.Authenticate(c); err != nil {
+			t.Errorf("Authenticate: %v", err)
+		}
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if sent != nil {
+		req.AddCookie(sent)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "ct" {
+			return c
+		}
+	}
+	t.Fatal("no ct cookie set")
+	return nil
+}
+
+func TestCookieAuthenticatorRenewsGuestCookie(t *testing.T) {
+	auth := CookieAuthenticator{AllowGuests: true, MaxAge: 720 * time.Hour}
+
+	first := guestCookie(t, auth, nil)
+	if first.Value == "" || first.MaxAge != int((720*time.Hour)/time.Second) {
+		t.Fatalf("new guest cookie = %+v, want a token living 720h", first)
+	}
+	again := guestCookie(t, auth, &http.Cookie{Name: "ct", Value: first.Value})
+	if again.Value != first.Value || again.MaxAge != first.MaxAge {
+		t.Fatalf("returning guest cookie = %+v, want %q renewed for 720h", again, first.Value)
+	}
+}
+
+func TestCookieAuthenticatorDefaultsToAWeek(t *testing.T) {
+	c := guestCookie(t, CookieAuthenticator{AllowGuests: true}, nil)
+	if c.MaxAge != int(clientCookieAge/time.Second) {
+		t.Fatalf("cookie max age = %d, want a week", c.MaxAge)
+	}
+}
@@ -0,0 +1,247 @@
+package http
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrNoToken  = errors.New("bearer token required")
+	ErrBadToken = errors.New("invalid token")
+)
+
+// JWTConfig describes the tokens a JWTAuthenticator accepts.
+type JWTConfig struct {
+	// Alg is one of HS256, HS384, HS512, RS256, RS384, RS512. Tokens
+	// signed with any other algorithm are refused.
+	Alg string
+	// KeyFile holds the HMAC secret as is, or the RSA public key as PEM
+	// (PKIX, PKCS#1 or a certificate).
+	KeyFile string
+	// Issuer and Audience, when set, must match iss and aud.
+	Issuer   string
+	Audience string
+	// Claim names; empty uses sub, name and roles.
+	UserClaim  string
+	NameClaim  string
+	RolesClaim string
+	// Leeway is the clock skew allowed on exp and nbf.
+	Leeway time.Duration
+}
+
+// JWTAuthenticator accepts bearer tokens issued elsewhere. The token comes
+// from the Authorization header or, for browsers opening a WebSocket, from
+// the access_token query parameter.
+type JWTAuthenticator struct {
+	cfg     JWTConfig
+	hash    crypto.Hash
+	hmacKey []byte
+	rsaKey  *rsa.PublicKey
+}
+
+// NewJWTAuthenticator loads the key named in cfg.
+func NewJWTAuthenticator(cfg JWTConfig) (*JWTAuthenticator, error) {
+	a := &JWTAuthenticator{cfg: cfg}
+	if a.cfg.UserClaim == "" {
+		a.cfg.UserClaim = "sub"
+	}
+	if a.cfg.NameClaim == "" {
+		a.cfg.NameClaim = "name"
+	}
+	if a.cfg.RolesClaim == "" {
+		a.cfg.RolesClaim = "roles"
+	}
+	if len(cfg.Alg) != 5 {
+		return nil, fmt.Errorf("jwt: unsupported alg %q", cfg.Alg)
+	}
+	switch cfg.Alg[2:] {
+	case "256":
+		a.hash = crypto.SHA256
+	case "384":
+		a.hash = crypto.SHA384
+	case "512":
+		a.hash = crypto.SHA512
+	default:
+		return nil, fmt.Errorf("jwt: unsupported alg %q", cfg.Alg)
+	}
+	key, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: %w", err)
+	}
+	switch cfg.Alg[:2] {
+	case "HS":
+		a.hmacKey = []byte(strings.TrimSpace(string(key)))
+		if len(a.hmacKey) == 0 {
+			return nil, errors.New("jwt: empty key file")
+		}
+	case "RS":
+		if a.rsaKey, err = parseRSAPublicKey(key); err != nil {
+			return nil, fmt.Errorf("jwt: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("jwt: unsupported alg %q", cfg.Alg)
+	}
+	return a, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(c *gin.Context) (core.Identity, error) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		token = c.Query("access_token")
+	}
+	if token == "" {
+		return core.Identity{}, ErrNoToken
+	}
+	claims, err := a.Verify(token, time.Now())
+	if err != nil {
+		return core.Identity{}, err
+	}
+	sub, _ := claims[a.cfg.UserClaim].(string)
+	if sub == "" || len(sub) > domain.MaxUserIDLen {
+		return core.Identity{}, fmt.Errorf("%w: bad %s claim", ErrBadToken, a.cfg.UserClaim)
+	}
+	name, _ := claims[a.cfg.NameClaim].(string)
+	return core.Identity{
+		Client: core.ClientID("jwt:" + sub),
+		User:   domain.UserID(sub),
+		Name:   name,
+		Roles:  stringList(claims[a.cfg.RolesClaim]),
+	}, nil
+}
+
+// Verify checks the signature and the registered claims of token and
+// returns its claims.
+func (a *JWTAuthenticator) Verify(token string, now time.Time) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrBadToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != a.cfg.Alg {
+		return nil, ErrBadToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrBadToken
+	}
+	if !a.verifySignature(parts[0]+"."+parts[1], sig) {
+		return nil, ErrBadToken
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrBadToken
+	}
+	// A token without exp would never expire, so it is not accepted.
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("%w: no expiry", ErrBadToken)
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(a.cfg.Leeway)) {
+		return nil, fmt.Errorf("%w: expired", ErrBadToken)
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(a.cfg.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return nil, fmt.Errorf("%w: not yet valid", ErrBadToken)
+	}
+	if a.cfg.Issuer != "" && claims["iss"] != a.cfg.Issuer {
+		return nil, fmt.Errorf("%w: wrong issuer", ErrBadToken)
+	}
+	if a.cfg.Audience != "" && !slices.Contains(stringList(claims["aud"]), a.cfg.Audience) {
+		return nil, fmt.Errorf("%w: wrong audience", ErrBadToken)
+	}
+	return claims, nil
+}
+
+func (a *JWTAuthenticator) verifySignature(signed string, sig []byte) bool {
+	if a.rsaKey != nil {
+		h := a.hash.New()
+		h.Write([]byte(signed))
+		return rsa.VerifyPKCS1v15(a.rsaKey, a.hash, h.Sum(nil), sig) == nil
+	}
+	var newHash func() hash.Hash
+	switch a.hash {
+	case crypto.SHA256:
+		newHash = sha256.New
+	case crypto.SHA384:
+		newHash = sha512.New384
+	default:
+		newHash = sha512.New
+	}
+	mac := hmac.New(newHash, a.hmacKey)
+	mac.Write([]byte(signed))
+	return hmac.Equal(mac.Sum(nil), sig)
+}
+
+func parseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block in key file")
+	}
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if k, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+			return k, nil
+		}
+	default:
+		k, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if k, ok := k.(*rsa.PublicKey); ok {
+			return k, nil
+		}
+	}
+	return nil, errors.New("key file holds no RSA public key")
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// stringList reads a claim that is either a list of strings or a single
+// space-separated string.
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package http
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var jwtNow = time.Unix(1_700_000_000, 0)
+
+func writeKeyFile(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func segment(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func signHS256(t *testing.T, key []byte, header, claims map[string]any) string {
+	t.Helper()
+	signed := segment(t, header) + "." + segment(t, claims)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, claims map[string]any) string {
+	t.Helper()
+	signed := segment(t, map[string]any{"alg": "RS256", "typ": "JWT"}) + "." + segment(t, claims)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestJWTVerifyHS256(t *testing.T) {
+	key := []byte("hmac-secret")
+	a, err := NewJWTAuthenticator(JWTConfig{
+		Alg:      "HS256",
+		KeyFile:  writeKeyFile(t, append(key, '\n')),
+		Issuer:   "issuer",
+		Audience: "voice",
+		Leeway:   30 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs := map[string]any{"alg": "HS256", "typ": "JWT"}
+	valid := func() map[string]any {
+		return map[string]any{
+			"sub": "user-1",
+			"iss": "issuer",
+			"aud": []string{"other", "voice"},
+			"exp": jwtNow.Add(time.Minute).Unix(),
+			"nbf": jwtNow.Add(-time.Minute).Unix(),
+		}
+	}
+	with := func(k string, v any) map[string]any {
+		c := valid()
+		if v == nil {
+			delete(c, k)
+		} else {
+			c[k] = v
+		}
+		return c
+	}
+
+	claims, err := a.Verify(signHS256(t, key, hs, valid()), jwtNow)
+	if err != nil {
+		t.Fatalf("valid token: %v", err)
+	}
+	if claims["sub"] != "user-1" {
+		t.Fatalf("sub = %v", claims["sub"])
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"expired", signHS256(t, key, hs, with("exp", jwtNow.Add(-time.Minute).Unix()))},
+		{"no expiry", signHS256(t, key, hs, with("exp", nil))},
+		{"not yet valid", signHS256(t, key, hs, with("nbf", jwtNow.Add(time.Minute).Unix()))},
+		{"wrong issuer", signHS256(t, key, hs, with("iss", "someone"))},
+		{"wrong audience", signHS256(t, key, hs, with("aud", "other"))},
+		{"no audience", signHS256(t, key, hs, with("aud", nil))},
+		{"wrong key", signHS256(t, []byte("guess"), hs, valid())},
+		{"alg none", signHS256(t, key, map[string]any{"alg": "none"}, valid())},
+		{"other alg", signHS256(t, key, map[string]any{"alg": "HS512"}, valid())},
+		{"two segments", segment(t, hs) + "." + segment(t, valid())},
+		{"garbage", "a.b.c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.Verify(tt.token, jwtNow); !errors.Is(err, ErrBadToken) {
+				t.Fatalf("Verify = %v, want %v", err, ErrBadToken)
+			}
+		})
+	}
+}
+
+func TestJWTVerifyLeeway(t *testing.T) {
+	key := []byte("hmac-secret")
+	a, err := NewJWTAuthenticator(JWTConfig{Alg: "HS256", KeyFile: writeKeyFile(t, key), Leeway: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := signHS256(t, key, map[string]any{"alg": "HS256"}, map[string]any{
+		"sub": "user-1",
+		"exp": jwtNow.Add(-30 * time.Second).Unix(),
+	})
+	if _, err := a.Verify(token, jwtNow); err != nil {
+		t.Fatalf("token expired within the leeway: %v", err)
+	}
+	if _, err := a.Verify(token, jwtNow.Add(time.Minute)); !errors.Is(err, ErrBadToken) {
+		t.Fatalf("token expired past the leeway = %v, want %v", err, ErrBadToken)
+	}
+}
+
+func TestJWTVerifyRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})
+	a, err := NewJWTAuthenticator(JWTConfig{Alg: "RS256", KeyFile: writeKeyFile(t, pemKey)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := map[string]any{"sub": "user-1", "exp": jwtNow.Add(time.Minute).Unix()}
+
+	if _, err := a.Verify(signRS256(t, key, claims), jwtNow); err != nil {
+		t.Fatalf("valid token: %v", err)
+	}
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Verify(signRS256(t, other, claims), jwtNow); !errors.Is(err, ErrBadToken) {
+		t.Fatalf("token from another key = %v, want %v", err, ErrBadToken)
+	}
+
+	// An HS256 token keyed with the public key must not pass as RS256.
+	confused := signHS256(t, pemKey, map[string]any{"alg": "HS256"}, claims)
+	if _, err := a.Verify(confused, jwtNow); !errors.Is(err, ErrBadToken) {
+		t.Fatalf("alg confusion = %v, want %v", err, ErrBadToken)
+	}
+}
+
+func TestNewJWTAuthenticatorRejectsUnknownAlg(t *testing.T) {
+	path := writeKeyFile(t, []byte("secret"))
+	for _, alg := range []string{"", "none", "ES256", "HS1024", "PS256"} {
+		if _, err := NewJWTAuthenticator(JWTConfig{Alg: alg, KeyFile: path}); err == nil {
+			t.Errorf("alg %q accepted", alg)
+		}
+	}
+}
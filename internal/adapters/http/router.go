@@ -2,6 +2,7 @@ package http
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/dkeye/Voice/internal/adapters/signal"
 	"github.com/dkeye/Voice/internal/app/orch"
@@ -18,32 +19,29 @@ func genClientToken() string {
 	return idStr
 }
 
-func ClientTokenMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		token, _ := c.Cookie("ct")
-		if token == "" {
-			token = genClientToken()
-			c.SetCookie("ct", token, 3600*24*7, "/", "", false, true)
-		}
-		c.Set("client_token", token)
-		c.Next()
-	}
-}
-
-func SetupRouter(ctx context.Context, cfg *config.Config, orch *orch.Orchestrator) *gin.Engine {
+// SetupRouter wires HTTP routes. handlers is the signal command registry;
+// embedders may extend signal.DefaultHandlers() before passing it in. auth
+// guards the API and the signal socket.
+func SetupRouter(ctx context.Context, cfg *config.Config, orch *orch.Orchestrator, handlers *signal.Handlers, auth Authenticator) *gin.Engine {
 	if cfg.Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	r := gin.New()
+	// Only a configured proxy may name the client address; anyone else
+	// could put a victim's address into X-Forwarded-For and dodge IP bans.
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Error().Err(err).Str("module", "adapters.http").Strs("trusted_proxies", cfg.TrustedProxies).Msg("bad trusted_proxies, trusting none")
+		_ = r.SetTrustedProxies(nil)
+	}
 	if cfg.Mode == "debug" {
 		r.Use(gin.Logger())
 	}
 	r.Use(gin.Recovery())
 
 	store := cookie.NewStore([]byte(cfg.Secret))
+	store.Options(sessions.Options{Path: "/", MaxAge: 3600 * 24 * 30, HttpOnly: true, SameSite: http.SameSiteLaxMode})
 	r.Use(sessions.Sessions("VoiceSessions", store))
-	r.Use(ClientTokenMiddleware())
 
 	r.Static("/static", cfg.StaticPath)
 	r.GET("/", func(c *gin.Context) {
@@ -53,13 +51,27 @@ func SetupRouter(ctx context.Context, cfg *config.Config, orch *orch.Orchestrato
 	log.Info().Str("module", "adapters.http").Str("static", cfg.StaticPath).Msg("router setup")
 
 	api := r.Group("/api")
+	// Accounts only make sense when the session cookie identifies the client.
+	if _, ok := auth.(CookieAuthenticator); ok {
+		registerAuthRoutes(api, orch.Registry)
+	}
+	api.Use(Authenticate(auth, orch))
+
+	api.GET("/rooms", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"rooms": orch.PublicRooms()})
+	})
+
+	api.GET("/stats", func(c *gin.Context) {
+		c.JSON(http.StatusOK, orch.Stats())
+	})
 
 	api.GET("/ws/signal", func(c *gin.Context) {
 		ctrl := signal.NewSignalWSController(
 			*orch,
 			cfg,
+			handlers,
 		)
-		log.Info().Str("module", "adapters.http").Str("sid", c.GetString("client_token")).Msg("ws signal endpoint hit")
+		log.Info().Str("module", "adapters.http").Str("client", c.GetString("client_token")).Str("account", c.GetString("account")).Msg("ws signal endpoint hit")
 		ctrl.HandleSignal(ctx, c)
 	})
 
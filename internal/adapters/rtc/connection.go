@@ -6,6 +6,7 @@ import (
 	"sync/atomic"
 
 	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
 	"github.com/pion/webrtc/v4"
 	"github.com/rs/zerolog/log"
 )
@@ -19,8 +20,12 @@ type WebRTCConnection struct {
 	onTrack             func(ctx context.Context, track *webrtc.TrackRemote, receiver *webrtc.RTPReceiver)
 	onNegotiationNeeded (func())
 	onClosed            (func())
+	onQuality           func(domain.ConnQuality)
 	closed              atomic.Bool
 
+	qmu     sync.Mutex
+	quality domain.ConnQuality
+
 	renegotiateMu sync.Mutex
 
 	once sync.Once
@@ -53,6 +58,12 @@ func (c *WebRTCConnection) Start(ctx context.Context) error {
 
 	c.pc.OnICEConnectionStateChange(func(s webrtc.ICEConnectionState) {
 		log.Info().Str("module", "webrtc").Str("sid", string(c.sid)).Str("ice_state", s.String()).Msg("ICE state")
+		switch s {
+		case webrtc.ICEConnectionStateConnected:
+			c.setQuality(domain.QualityGood)
+		case webrtc.ICEConnectionStateDisconnected:
+			c.setQuality(domain.QualityPoor)
+		}
 		if s == webrtc.ICEConnectionStateDisconnected ||
 			s == webrtc.ICEConnectionStateFailed ||
 			s == webrtc.ICEConnectionStateClosed {
@@ -87,17 +98,27 @@ func (c *WebRTCConnection) Start(ctx context.Context) error {
 		}
 	})
 
-	c.pc.OnNegotiationNeeded(func() {
-		if c.onNegotiationNeeded != nil {
-			c.renegotiateMu.Lock()
-			defer c.renegotiateMu.Unlock()
-			c.onNegotiationNeeded()
-		}
-	})
+	c.pc.OnNegotiationNeeded(c.negotiate)
 
+	go c.watchQuality(ctx)
 	return nil
 }
 
+// Renegotiate re-offers on the existing PeerConnection through the same path
+// pion's negotiation-needed event takes.
+func (c *WebRTCConnection) Renegotiate() {
+	go c.negotiate()
+}
+
+func (c *WebRTCConnection) negotiate() {
+	if c.onNegotiationNeeded == nil || c.IsClosed() {
+		return
+	}
+	c.renegotiateMu.Lock()
+	defer c.renegotiateMu.Unlock()
+	c.onNegotiationNeeded()
+}
+
 func (c *WebRTCConnection) ApplyOffer(offer webrtc.SessionDescription) error {
 	return c.pc.SetRemoteDescription(offer)
 }
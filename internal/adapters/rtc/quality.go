@@ -0,0 +1,88 @@
+package rtc
+
+import (
+	"context"
+	"time"
+
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/pion/webrtc/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// qualityInterval is how often connection stats are sampled.
+const qualityInterval = 5 * time.Second
+
+// rateQuality maps round-trip time (seconds) and the share of lost inbound
+// packets over the last interval to a coarse rating.
+func rateQuality(rtt, loss float64) domain.ConnQuality {
+	switch {
+	case loss >= 0.08 || rtt >= 0.5:
+		return domain.QualityPoor
+	case loss >= 0.02 || rtt >= 0.25:
+		return domain.QualityFair
+	default:
+		return domain.QualityGood
+	}
+}
+
+// OnQualityChange sets a callback for changes of the coarse connection quality.
+func (c *WebRTCConnection) OnQualityChange(fn func(domain.ConnQuality)) { c.onQuality = fn }
+
+func (c *WebRTCConnection) setQuality(q domain.ConnQuality) {
+	c.qmu.Lock()
+	changed := c.quality != q
+	c.quality = q
+	c.qmu.Unlock()
+	if changed && c.onQuality != nil {
+		c.onQuality(q)
+	}
+}
+
+// watchQuality samples stats until ctx ends and reports rating changes.
+func (c *WebRTCConnection) watchQuality(ctx context.Context) {
+	t := time.NewTicker(qualityInterval)
+	defer t.Stop()
+
+	var prevRecv, prevLost int64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		rtt, recv, lost := c.sampleStats()
+		dRecv, dLost := recv-prevRecv, lost-prevLost
+		prevRecv, prevLost = recv, lost
+
+		var loss float64
+		if total := dRecv + dLost; total > 0 && dLost > 0 {
+			loss = float64(dLost) / float64(total)
+		}
+		q := rateQuality(rtt, loss)
+		log.Debug().
+			Str("module", "webrtc").
+			Str("sid", string(c.sid)).
+			Float64("rtt", rtt).
+			Float64("loss", loss).
+			Str("quality", string(q)).
+			Msg("quality sample")
+		c.setQuality(q)
+	}
+}
+
+// sampleStats returns the RTT of the selected candidate pair and the
+// cumulative received and lost packet counts over all inbound streams.
+func (c *WebRTCConnection) sampleStats() (rtt float64, recv, lost int64) {
+	for _, s := range c.pc.GetStats() {
+		switch st := s.(type) {
+		case webrtc.ICECandidatePairStats:
+			if st.Nominated && st.State == webrtc.StatsICECandidatePairStateSucceeded {
+				rtt = st.CurrentRoundTripTime
+			}
+		case webrtc.InboundRTPStreamStats:
+			recv += int64(st.PacketsReceived)
+			lost += int64(st.PacketsLost)
+		}
+	}
+	return rtt, recv, lost
+}
@@ -0,0 +1,63 @@
+package signal
+
+import (
+	"github.com/dkeye/Voice/internal/protocol"
+	"github.com/rs/zerolog/log"
+)
+
+func (ctl *SignalWSController) handleChatMessage(req *Request) {
+	var p protocol.ChatSend
+	if err := req.Decode(&p); err != nil {
+		log.Error().Err(err).Str("module", "signal").Msg("bad chat_message payload")
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
+		return
+	}
+	room, msg, err := ctl.Orch.SendChat(req.SID, p.Text)
+	if err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	ctl.Ack(req)
+	ctl.BroadcastRoom(room.Room().ID, &protocol.Chat{
+		Header:  protocol.Header{Type: protocol.TypeChatMessage},
+		Message: msg,
+	})
+}
+
+func (ctl *SignalWSController) handleChatEdit(req *Request) {
+	var p protocol.ChatEdit
+	if err := req.Decode(&p); err != nil {
+		log.Error().Err(err).Str("module", "signal").Msg("bad chat_edit payload")
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
+		return
+	}
+	room, msg, err := ctl.Orch.EditChat(req.SID, p.Msg, p.Text)
+	if err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	ctl.Ack(req)
+	ctl.BroadcastRoom(room.Room().ID, &protocol.Chat{
+		Header:  protocol.Header{Type: protocol.TypeChatEdited},
+		Message: msg,
+	})
+}
+
+func (ctl *SignalWSController) handleChatDelete(req *Request) {
+	var p protocol.ChatEdit
+	if err := req.Decode(&p); err != nil {
+		log.Error().Err(err).Str("module", "signal").Msg("bad chat_delete payload")
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
+		return
+	}
+	room, err := ctl.Orch.DeleteChat(req.SID, p.Msg)
+	if err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	ctl.Ack(req)
+	ctl.BroadcastRoom(room.Room().ID, &protocol.ChatDeleted{
+		Header: protocol.Header{Type: protocol.TypeChatDeleted},
+		Msg:    p.Msg,
+	})
+}
@@ -1,12 +1,7 @@
 package signal
 
-func (ctl *SignalWSController) handlePing(
-	conn *WsSignalConn,
-) {
-	resp := struct {
-		Type string `json:"type"`
-	}{
-		Type: "pong",
-	}
-	ctl.sendJSON(conn, resp)
+import "github.com/dkeye/Voice/internal/protocol"
+
+func (ctl *SignalWSController) handlePing(req *Request) {
+	ctl.Reply(req, protocol.Simple(protocol.TypePong))
 }
@@ -0,0 +1,70 @@
+package signal
+
+import (
+	"errors"
+
+	"github.com/dkeye/Voice/internal/app"
+	"github.com/dkeye/Voice/internal/app/orch"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/dkeye/Voice/internal/protocol"
+)
+
+// errorCode maps app errors to stable wire error codes.
+func errorCode(err error) protocol.ErrorCode {
+	switch {
+	case errors.Is(err, orch.ErrAlreadyInRoom):
+		return protocol.ErrAlreadyInRoom
+	case errors.Is(err, orch.ErrRoomNotFound):
+		return protocol.ErrRoomNotFound
+	case errors.Is(err, orch.ErrRoomFull):
+		return protocol.ErrRoomFull
+	case errors.Is(err, orch.ErrServerBusy):
+		return protocol.ErrServerBusy
+	case errors.Is(err, orch.ErrNotInRoom):
+		return protocol.ErrNotInRoom
+	case errors.Is(err, orch.ErrNoSession):
+		return protocol.ErrNoSession
+	case errors.Is(err, orch.ErrNotModerator), errors.Is(err, orch.ErrNotOwner), errors.Is(err, orch.ErrOutranked), errors.Is(err, orch.ErrNotAdmin):
+		return protocol.ErrForbidden
+	case errors.Is(err, orch.ErrAccessDenied):
+		return protocol.ErrAccessDenied
+	case errors.Is(err, orch.ErrNoSuchMember):
+		return protocol.ErrNoSuchMember
+	case errors.Is(err, orch.ErrBadRole):
+		return protocol.ErrBadRole
+	case errors.Is(err, orch.ErrNotStage):
+		return protocol.ErrNotStage
+	case errors.Is(err, orch.ErrAlreadySpeaker):
+		return protocol.ErrAlreadySpeaker
+	case errors.Is(err, orch.ErrAudience):
+		return protocol.ErrAudience
+	case errors.Is(err, orch.ErrNoFloorControl):
+		return protocol.ErrNoFloorControl
+	case errors.Is(err, orch.ErrNoTargets):
+		return protocol.ErrNoTargets
+	case errors.Is(err, orch.ErrNotWhispering):
+		return protocol.ErrNotWhispering
+	case errors.Is(err, orch.ErrBanned):
+		return protocol.ErrBanned
+	case errors.Is(err, orch.ErrNotBanned):
+		return protocol.ErrNotBanned
+	case errors.Is(err, app.ErrBadPassword):
+		return protocol.ErrBadPassword
+	case errors.Is(err, orch.ErrInviteRequired):
+		return protocol.ErrInviteRequired
+	case errors.Is(err, app.ErrBadInvite):
+		return protocol.ErrInvalidInvite
+	case errors.Is(err, app.ErrInviteExpired):
+		return protocol.ErrInviteExpired
+	case errors.Is(err, app.ErrInviteUsedUp):
+		return protocol.ErrInviteUsedUp
+	case errors.Is(err, orch.ErrNoSuchMessage):
+		return protocol.ErrNoSuchMessage
+	case errors.Is(err, domain.ErrChatTextEmpty), errors.Is(err, domain.ErrChatTextTooLong):
+		return protocol.ErrInvalidMessage
+	case errors.Is(err, domain.ErrUsernameEmpty), errors.Is(err, domain.ErrUsernameTooLong):
+		return protocol.ErrInvalidName
+	default:
+		return protocol.ErrInternal
+	}
+}
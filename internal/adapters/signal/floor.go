@@ -0,0 +1,59 @@
+package signal
+
+import (
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/protocol"
+	"github.com/rs/zerolog/log"
+)
+
+func floorChangedMessage(e core.FloorChanged) *protocol.FloorChanged {
+	return &protocol.FloorChanged{
+		Header: protocol.Header{Type: protocol.TypeFloorChanged},
+		Holder: e.Holder,
+		Queue:  e.Queue,
+		Reason: e.Reason,
+	}
+}
+
+func (ctl *SignalWSController) handleRequestFloor(req *Request) {
+	granted, err := ctl.Orch.RequestFloor(req.SID)
+	if err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	log.Info().Str("module", "signal").Str("sid", string(req.SID)).Bool("granted", granted).Msg("request floor")
+	ctl.Ack(req)
+}
+
+func (ctl *SignalWSController) handleReleaseFloor(req *Request) {
+	if err := ctl.Orch.ReleaseFloor(req.SID); err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	log.Info().Str("module", "signal").Str("sid", string(req.SID)).Msg("release floor")
+	ctl.Ack(req)
+}
+
+func (ctl *SignalWSController) handleGrantFloor(req *Request) {
+	var p protocol.Target
+	if err := req.Decode(&p); err != nil {
+		log.Error().Err(err).Str("module", "signal").Msg("bad grant_floor payload")
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
+		return
+	}
+	if err := ctl.Orch.GrantFloor(req.SID, p.User); err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	log.Info().Str("module", "signal").Str("sid", string(req.SID)).Str("user", string(p.User)).Msg("grant floor")
+	ctl.Ack(req)
+}
+
+func (ctl *SignalWSController) handleRevokeFloor(req *Request) {
+	if err := ctl.Orch.RevokeFloor(req.SID); err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	log.Info().Str("module", "signal").Str("sid", string(req.SID)).Msg("revoke floor")
+	ctl.Ack(req)
+}
@@ -0,0 +1,234 @@
+package signal
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/dkeye/Voice/internal/protocol"
+	"github.com/rs/zerolog/log"
+)
+
+// Request is one decoded client command together with its origin.
+type Request struct {
+	SID    core.SessionID
+	Conn   *WsSignalConn
+	Header protocol.Header
+	// Data is the raw command, including the header fields.
+	Data []byte
+}
+
+// Decode unmarshals the full command into a typed protocol message
+// using the connection's codec.
+func (req *Request) Decode(v any) error {
+	return req.Conn.codec.Unmarshal(req.Data, v)
+}
+
+// HandlerFunc processes one command type. It must complete the command
+// with exactly one Reply, Ack or Fail.
+type HandlerFunc func(ctl *SignalWSController, req *Request)
+
+// Middleware wraps a handler; it may run code around next or short-circuit
+// by failing the request without calling next.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+type route struct {
+	handler    HandlerFunc
+	middleware []Middleware
+}
+
+// Handlers maps message types to handlers and runs middleware around them.
+// It is built once and shared by every connection; registration is safe at
+// any time.
+type Handlers struct {
+	mu         sync.RWMutex
+	routes     map[protocol.Type]route
+	middleware []Middleware
+}
+
+func NewHandlers() *Handlers {
+	return &Handlers{routes: make(map[protocol.Type]route)}
+}
+
+// DefaultHandlers returns a registry with every built-in command plus
+// panic recovery and debug logging.
+func DefaultHandlers() *Handlers {
+	h := NewHandlers()
+	h.Use(Recover(), Logging())
+	RegisterBuiltins(h)
+	return h
+}
+
+// Handle registers fn for t, replacing any previous handler. Middleware
+// passed here runs inside the global middleware, for this type only.
+func (h *Handlers) Handle(t protocol.Type, fn HandlerFunc, mw ...Middleware) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.routes[t] = route{handler: fn, middleware: mw}
+}
+
+// Use appends global middleware. The first registered runs outermost.
+func (h *Handlers) Use(mw ...Middleware) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.middleware = append(h.middleware, mw...)
+}
+
+// Dispatch runs the handler for req's type, failing unknown types.
+func (h *Handlers) Dispatch(ctl *SignalWSController, req *Request) {
+	h.mu.RLock()
+	r, ok := h.routes[req.Header.Type]
+	global := h.middleware
+	h.mu.RUnlock()
+
+	fn := r.handler
+	if !ok {
+		fn = unknownType
+	}
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		fn = r.middleware[i](fn)
+	}
+	for i := len(global) - 1; i >= 0; i-- {
+		fn = global[i](fn)
+	}
+	fn(ctl, req)
+}
+
+func unknownType(ctl *SignalWSController, req *Request) {
+	log.Warn().Str("module", "signal").Str("type", string(req.Header.Type)).Msg("unknown signal")
+	ctl.Fail(req, protocol.ErrUnknownType, string(req.Header.Type))
+}
+
+// RegisterBuiltins installs the handlers for every command of the protocol.
+func RegisterBuiltins(h *Handlers) {
+	h.Handle(protocol.TypeCreateRoom, (*SignalWSController).createRoom)
+	h.Handle(protocol.TypeJoin, (*SignalWSController).handleJoin)
+	h.Handle(protocol.TypeLeave, (*SignalWSController).handleLeave)
+	h.Handle(protocol.TypePing, (*SignalWSController).handlePing)
+	h.Handle(protocol.TypeRename, (*SignalWSController).handleRename)
+	h.Handle(protocol.TypeWhoAmI, (*SignalWSController).handleWhoAmI)
+	h.Handle(protocol.TypeSetPrefs, (*SignalWSController).handleSetPrefs)
+	h.Handle(protocol.TypeOffer, (*SignalWSController).handleOffer)
+	h.Handle(protocol.TypeAnswer, (*SignalWSController).handleAnswer)
+	h.Handle(protocol.TypeCandidate, (*SignalWSController).handleCandidate)
+	h.Handle(protocol.TypeDeafen, func(ctl *SignalWSController, req *Request) {
+		ctl.handleDeafen(req, true)
+	})
+	h.Handle(protocol.TypeUndeafen, func(ctl *SignalWSController, req *Request) {
+		ctl.handleDeafen(req, false)
+	})
+	h.Handle(protocol.TypeMute, func(ctl *SignalWSController, req *Request) {
+		ctl.handleMute(req, true)
+	})
+	h.Handle(protocol.TypeUnmute, func(ctl *SignalWSController, req *Request) {
+		ctl.handleMute(req, false)
+	})
+	h.Handle(protocol.TypeWhisperStart, (*SignalWSController).handleWhisperStart)
+	h.Handle(protocol.TypeWhisperStop, (*SignalWSController).handleWhisperStop)
+	h.Handle(protocol.TypeRaiseHand, (*SignalWSController).handleRaiseHand)
+	h.Handle(protocol.TypeLowerHand, (*SignalWSController).handleLowerHand)
+	h.Handle(protocol.TypePromote, func(ctl *SignalWSController, req *Request) {
+		ctl.handleStageRole(req, domain.StageSpeaker)
+	})
+	h.Handle(protocol.TypeDemote, func(ctl *SignalWSController, req *Request) {
+		ctl.handleStageRole(req, domain.StageAudience)
+	})
+	h.Handle(protocol.TypeKick, (*SignalWSController).handleKick)
+	h.Handle(protocol.TypeBan, (*SignalWSController).handleBan)
+	h.Handle(protocol.TypeUnban, (*SignalWSController).handleUnban)
+	h.Handle(protocol.TypeListBans, (*SignalWSController).handleListBans)
+	h.Handle(protocol.TypeMuteMember, func(ctl *SignalWSController, req *Request) {
+		ctl.handleMuteMember(req, true)
+	})
+	h.Handle(protocol.TypeUnmuteMember, func(ctl *SignalWSController, req *Request) {
+		ctl.handleMuteMember(req, false)
+	})
+	h.Handle(protocol.TypeTransferOwner, (*SignalWSController).handleTransferOwnership)
+	h.Handle(protocol.TypeCloseRoom, (*SignalWSController).handleCloseRoom)
+	h.Handle(protocol.TypeRequestFloor, (*SignalWSController).handleRequestFloor)
+	h.Handle(protocol.TypeReleaseFloor, (*SignalWSController).handleReleaseFloor)
+	h.Handle(protocol.TypeGrantFloor, (*SignalWSController).handleGrantFloor)
+	h.Handle(protocol.TypeRevokeFloor, (*SignalWSController).handleRevokeFloor)
+	h.Handle(protocol.TypeCreateInvite, (*SignalWSController).handleCreateInvite)
+	h.Handle(protocol.TypeListRooms, (*SignalWSController).handleListRooms)
+	h.Handle(protocol.TypeSubscribe, (*SignalWSController).handleSubscribeRooms)
+	h.Handle(protocol.TypeUnsubscribe, (*SignalWSController).handleUnsubscribeRooms)
+	h.Handle(protocol.TypeChatMessage, (*SignalWSController).handleChatMessage, RateLimit(20, 10*time.Second))
+	h.Handle(protocol.TypeChatEdit, (*SignalWSController).handleChatEdit)
+	h.Handle(protocol.TypeChatDelete, (*SignalWSController).handleChatDelete)
+}
+
+// Recover turns a panicking handler into an "internal" error for the client.
+func Recover() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctl *SignalWSController, req *Request) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error().
+						Str("module", "signal").
+						Str("sid", string(req.SID)).
+						Str("type", string(req.Header.Type)).
+						Str("panic", fmt.Sprint(r)).
+						Msg("handler panic")
+					ctl.Fail(req, protocol.ErrInternal, "")
+				}
+			}()
+			next(ctl, req)
+		}
+	}
+}
+
+// Logging logs every command with its duration at debug level.
+func Logging() Middleware {
+	return Observe(func(req *Request, d time.Duration) {
+		log.Debug().
+			Str("module", "signal").
+			Str("sid", string(req.SID)).
+			Str("type", string(req.Header.Type)).
+			Str("id", req.Header.ID).
+			Dur("took", d).
+			Msg("handled")
+	})
+}
+
+// Observe calls fn after every command; use it to feed metrics.
+func Observe(fn func(req *Request, d time.Duration)) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctl *SignalWSController, req *Request) {
+			start := time.Now()
+			next(ctl, req)
+			fn(req, time.Since(start))
+		}
+	}
+}
+
+// RateLimit allows each user at most limit commands per interval.
+func RateLimit(limit int, interval time.Duration) Middleware {
+	limiter := NewRoomRateLimiter(limit, interval)
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctl *SignalWSController, req *Request) {
+			user, err := ctl.Orch.Registry.GetOrCreateUser(req.SID)
+			if err == nil && !limiter.Allow(user.ID) {
+				ctl.Fail(req, protocol.ErrRateLimited, "")
+				return
+			}
+			next(ctl, req)
+		}
+	}
+}
+
+// Require runs next only if allow accepts the request; otherwise it fails
+// with code. Use it for auth checks on selected types.
+func Require(allow func(ctl *SignalWSController, req *Request) bool, code protocol.ErrorCode) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctl *SignalWSController, req *Request) {
+			if !allow(ctl, req) {
+				ctl.Fail(req, code, "")
+				return
+			}
+			next(ctl, req)
+		}
+	}
+}
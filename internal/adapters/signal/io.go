@@ -2,15 +2,33 @@ package signal
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"net"
 	"time"
 
 	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/protocol"
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
 )
 
+const writeWait = 5 * time.Second
+
+// pongWait is how long the read side waits for any frame before it
+// declares the peer dead. Pings go out every pingPeriod, so a healthy
+// peer always answers well inside the window.
+func (ctl *SignalWSController) pongWait() time.Duration {
+	return ctl.pingPeriod * 10 / 9
+}
+
 func (ctl *SignalWSController) writePump(ctx context.Context, c *WsSignalConn) {
+	var tick <-chan time.Time
+	if ctl.pingPeriod > 0 {
+		ticker := time.NewTicker(ctl.pingPeriod)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -21,12 +39,20 @@ func (ctl *SignalWSController) writePump(ctx context.Context, c *WsSignalConn) {
 				log.Warn().Str("module", "signal").Msg("writePump channel closed")
 				return
 			}
-			if err := c.conn.SetWriteDeadline(time.Now().Add(5 * time.Second)); err != nil {
+			if err := c.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
 				log.Error().Err(err).Str("module", "signal").Msg("writePump set deadline")
+				c.Close()
 				return
 			}
-			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			if err := c.conn.WriteMessage(c.messageType(), data); err != nil {
 				log.Error().Err(err).Str("module", "signal").Msg("writePump write error")
+				c.Close()
+				return
+			}
+		case <-tick:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				log.Warn().Err(err).Str("module", "signal").Msg("writePump ping failed")
+				c.Close()
 				return
 			}
 		}
@@ -36,10 +62,24 @@ func (ctl *SignalWSController) writePump(ctx context.Context, c *WsSignalConn) {
 func (ctl *SignalWSController) readPump(ctx context.Context, sid core.SessionID, c *WsSignalConn) {
 	defer func() {
 		log.Info().Str("module", "signal").Str("sid", string(sid)).Msg("readPump closing")
+		// Detach before closing: the janitor reaps sessions with a closed
+		// signal and no resume grace, and would otherwise catch this one
+		// in between.
+		ctl.disconnect(sid, c)
 		c.Close()
-		go ctl.handleLeave(sid, c)
 	}()
 
+	if ctl.readLimit > 0 {
+		c.conn.SetReadLimit(ctl.readLimit)
+	}
+	if ctl.pingPeriod > 0 {
+		wait := ctl.pongWait()
+		_ = c.conn.SetReadDeadline(time.Now().Add(wait))
+		c.conn.SetPongHandler(func(string) error {
+			return c.conn.SetReadDeadline(time.Now().Add(wait))
+		})
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -48,58 +88,74 @@ func (ctl *SignalWSController) readPump(ctx context.Context, sid core.SessionID,
 		default:
 			_, data, err := c.conn.ReadMessage()
 			if err != nil {
-				log.Error().Err(err).Str("module", "signal").Str("sid", string(sid)).Msg("readPump read error")
+				var ne net.Error
+				if errors.As(err, &ne) && ne.Timeout() {
+					log.Warn().Str("module", "signal").Str("sid", string(sid)).Msg("readPump peer timed out")
+				} else {
+					log.Error().Err(err).Str("module", "signal").Str("sid", string(sid)).Msg("readPump read error")
+				}
 				return
 			}
+			if ctl.pingPeriod > 0 {
+				_ = c.conn.SetReadDeadline(time.Now().Add(ctl.pongWait()))
+			}
 			ctl.handleSignal(sid, c, data)
 		}
 	}
 }
 
 func (ctl *SignalWSController) handleSignal(sid core.SessionID, c *WsSignalConn, data []byte) {
-	var env struct {
-		Type string `json:"type"`
+	req := &Request{SID: sid, Conn: c, Data: data}
+	if err := c.codec.Unmarshal(data, &req.Header); err != nil {
+		log.Error().Err(err).Str("module", "signal").Str("codec", c.codec.Subprotocol()).Msg("bad payload")
+		ctl.Fail(req, protocol.ErrBadPayload, "malformed message")
+		return
 	}
-	if err := json.Unmarshal(data, &env); err != nil {
-		log.Error().Err(err).Str("module", "signal").Msg("bad json")
-		ctl.sendJSON(c, map[string]any{
-			"type":  "error",
-			"error": "bad_payload",
-		})
+	if req.Header.V > protocol.Version {
+		ctl.Fail(req, protocol.ErrUnsupportedVersion, "server speaks an older protocol version")
 		return
 	}
+	ctl.handlers.Dispatch(ctl, req)
+}
 
-	switch env.Type {
-	case "create_room":
-		ctl.createRoom(sid, c, data)
-	case "join":
-		ctl.handleJoin(sid, c, data)
-	case "leave":
-		ctl.handleLeave(sid, c)
-	case "ping":
-		ctl.handlePing(c)
-	case "rename":
-		ctl.handleRename(sid, c, data)
-	case "whoami":
-		ctl.handleWhoAmI(sid, c)
-	case "offer":
-		ctl.handleOffer(sid, c, data)
-	case "answer":
-		ctl.handleAnswer(sid, c, data)
-	case "candidate":
-		ctl.handleCandidate(sid, c, data)
-	default:
-		log.Warn().Str("module", "signal").Str("type", env.Type).Msg("unknown signal")
-	}
+// Reply answers req with m, echoing the command ID.
+func (ctl *SignalWSController) Reply(req *Request, m protocol.Reply) {
+	m.SetID(req.Header.ID)
+	ctl.send(req.Conn, m)
+}
+
+// Ack completes a command that has no dedicated reply.
+func (ctl *SignalWSController) Ack(req *Request) {
+	ctl.Reply(req, protocol.NewAck(req.Header.Type))
+}
+
+// Fail answers req with a typed error.
+func (ctl *SignalWSController) Fail(req *Request, code protocol.ErrorCode, message string) {
+	ctl.Reply(req, protocol.NewError(code, message))
+}
+
+// FailErr answers req with the error code matching an app error.
+func (ctl *SignalWSController) FailErr(req *Request, err error) {
+	ctl.Fail(req, errorCode(err), "")
 }
 
-func (ctl *SignalWSController) sendJSON(c core.SignalConnection, v any) {
-	b, err := json.Marshal(v)
+func (ctl *SignalWSController) send(c core.SignalConnection, v any) {
+	send(c, v)
+}
+
+// send encodes v with the codec negotiated for c and queues the frame.
+// Connections that do not negotiate an encoding get JSON.
+func send(c core.SignalConnection, v any) {
+	codec := protocol.JSON
+	if cc, ok := c.(interface{ Codec() protocol.Codec }); ok {
+		codec = cc.Codec()
+	}
+	b, err := codec.Marshal(v)
 	if err != nil {
-		log.Error().Err(err).Str("module", "signal").Msg("sendJSON marshal")
+		log.Error().Err(err).Str("module", "signal").Str("codec", codec.Subprotocol()).Msg("send marshal")
 		return
 	}
 	if err := c.TrySend(b); err != nil {
-		log.Debug().Err(err).Msg("sendJSON skipped (conn closed)")
+		log.Debug().Err(err).Msg("send skipped (conn closed)")
 	}
 }
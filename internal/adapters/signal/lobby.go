@@ -0,0 +1,34 @@
+package signal
+
+import (
+	"github.com/dkeye/Voice/internal/protocol"
+	"github.com/rs/zerolog/log"
+)
+
+func (ctl *SignalWSController) roomsMessage() *protocol.Rooms {
+	return &protocol.Rooms{
+		Header: protocol.Header{Type: protocol.TypeRooms},
+		Rooms:  ctl.Orch.PublicRooms(),
+	}
+}
+
+func (ctl *SignalWSController) handleListRooms(req *Request) {
+	ctl.Reply(req, ctl.roomsMessage())
+}
+
+// handleSubscribeRooms answers with the current list and then streams
+// room_listed changes until unsubscribe_rooms.
+func (ctl *SignalWSController) handleSubscribeRooms(req *Request) {
+	if !ctl.Orch.Registry.SetLobby(req.SID, true) {
+		ctl.Fail(req, protocol.ErrNoSession, "")
+		return
+	}
+	log.Info().Str("module", "signal").Str("sid", string(req.SID)).Msg("lobby subscribe")
+	ctl.Reply(req, ctl.roomsMessage())
+}
+
+func (ctl *SignalWSController) handleUnsubscribeRooms(req *Request) {
+	ctl.Orch.Registry.SetLobby(req.SID, false)
+	log.Info().Str("module", "signal").Str("sid", string(req.SID)).Msg("lobby unsubscribe")
+	ctl.Ack(req)
+}
@@ -0,0 +1,129 @@
+package signal
+
+import (
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/dkeye/Voice/internal/protocol"
+	"github.com/rs/zerolog/log"
+)
+
+func (ctl *SignalWSController) handleKick(req *Request) {
+	var p protocol.Kick
+	if err := req.Decode(&p); err != nil {
+		log.Error().Err(err).Str("module", "signal").Msg("bad kick payload")
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
+		return
+	}
+	room, targetSID, err := ctl.Orch.Kick(req.SID, p.User, p.Reason)
+	if err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	ctl.announceLeft(room.Room().ID, targetSID)
+	ctl.Ack(req)
+}
+
+func (ctl *SignalWSController) handleBan(req *Request) {
+	var p protocol.Ban
+	if err := req.Decode(&p); err != nil || p.Duration < 0 {
+		log.Error().Err(err).Str("module", "signal").Msg("bad ban payload")
+		ctl.Fail(req, protocol.ErrBadPayload, "")
+		return
+	}
+	d := time.Duration(p.Duration) * time.Second
+	room, targetSID, err := ctl.Orch.Ban(req.SID, p.User, p.Reason, d, p.IP)
+	if err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	if targetSID != "" {
+		ctl.announceLeft(room.Room().ID, targetSID)
+	}
+	ctl.Ack(req)
+}
+
+func (ctl *SignalWSController) handleUnban(req *Request) {
+	var p protocol.Target
+	if err := req.Decode(&p); err != nil {
+		log.Error().Err(err).Str("module", "signal").Msg("bad unban payload")
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
+		return
+	}
+	if err := ctl.Orch.Unban(req.SID, p.User); err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	ctl.Ack(req)
+}
+
+func (ctl *SignalWSController) handleListBans(req *Request) {
+	bans, err := ctl.Orch.Bans(req.SID)
+	if err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	ctl.Reply(req, &protocol.Bans{
+		Header: protocol.Header{Type: protocol.TypeBans},
+		Bans:   bans,
+	})
+}
+
+func (ctl *SignalWSController) handleMuteMember(req *Request, muted bool) {
+	var p protocol.Target
+	if err := req.Decode(&p); err != nil {
+		log.Error().Err(err).Str("module", "signal").Msg("bad mute_member payload")
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
+		return
+	}
+	if _, err := ctl.Orch.MuteMember(req.SID, p.User, muted); err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	ctl.Ack(req)
+}
+
+// handleRoomRole serves promote/demote carrying a room role.
+func (ctl *SignalWSController) handleRoomRole(req *Request, p protocol.Target) {
+	role := p.Role
+	if req.Header.Type == protocol.TypeDemote {
+		// Demoting always lands on a plain member; the role names what is taken away.
+		if role != domain.RoleModerator {
+			ctl.Fail(req, protocol.ErrBadRole, string(role))
+			return
+		}
+		role = domain.RoleMember
+	}
+	if _, err := ctl.Orch.SetRoomRole(req.SID, p.User, role); err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	ctl.Ack(req)
+}
+
+func (ctl *SignalWSController) handleTransferOwnership(req *Request) {
+	var p protocol.Target
+	if err := req.Decode(&p); err != nil {
+		log.Error().Err(err).Str("module", "signal").Msg("bad transfer_ownership payload")
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
+		return
+	}
+	if _, err := ctl.Orch.TransferOwnership(req.SID, p.User); err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	ctl.Ack(req)
+}
+
+// handleCloseRoom closes the room for everyone; members get room_closed.
+func (ctl *SignalWSController) handleCloseRoom(req *Request) {
+	room, err := ctl.Orch.CloseRoom(req.SID)
+	if err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	log.Info().Str("module", "signal").Str("sid", string(req.SID)).Str("room_id", string(room.Room().ID)).Msg("close room")
+	ctl.Orch.EvictRoom(room.Room().ID, core.RoomClosedByOwner)
+	ctl.Ack(req)
+}
@@ -0,0 +1,97 @@
+package signal
+
+import (
+	"github.com/dkeye/Voice/internal/app"
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/dkeye/Voice/internal/protocol"
+	"github.com/rs/zerolog/log"
+)
+
+// Notifier implements core.EventSink by translating app events into signal
+// messages and fanning them out over the registry's signal connections.
+type Notifier struct {
+	registry *app.Registry
+}
+
+func NewNotifier(registry *app.Registry) *Notifier {
+	return &Notifier{registry: registry}
+}
+
+func (n *Notifier) Publish(ev core.Event) {
+	switch e := ev.(type) {
+	case core.FloorChanged:
+		n.broadcastRoom(e.Room, floorChangedMessage(e))
+	case core.WhisperChanged:
+		n.sendTo(e.To, whisperMessage(e))
+	case core.MemberChanged:
+		n.broadcastRoom(e.Room, &protocol.MemberEvent{
+			Header:  protocol.Header{Type: protocol.TypeMemberUpdated},
+			User:    e.User,
+			Changes: &e.Changes,
+		})
+	case core.RoomListChanged:
+		msg := &protocol.RoomListed{
+			Header: protocol.Header{Type: protocol.TypeRoomListed},
+			Room:   e.Room,
+			Change: e.Change,
+		}
+		for _, snap := range n.registry.LobbySessions() {
+			send(snap.Session.Signal(), msg)
+		}
+	case core.MemberKicked:
+		n.sendTo([]core.SessionID{e.SID}, &protocol.Kicked{
+			Header:  protocol.Header{Type: protocol.TypeKicked},
+			Room:    e.Room,
+			Reason:  e.Reason,
+			Message: e.Note,
+			Until:   e.Until,
+		})
+	case core.MemberLeft:
+		n.broadcastRoom(e.Room, &protocol.MemberEvent{
+			Header: protocol.Header{Type: protocol.TypeMemberLeft},
+			User:   e.User,
+		})
+	case core.RoomClosed:
+		n.roomClosed(e)
+	default:
+		log.Warn().Str("module", "signal").Type("event", ev).Msg("unknown event")
+	}
+}
+
+// roomClosed reaches the members first and then lobby subscribers who
+// were not in the room.
+func (n *Notifier) roomClosed(e core.RoomClosed) {
+	msg := &protocol.RoomClosed{
+		Header: protocol.Header{Type: protocol.TypeRoomClosed},
+		Room:   e.Room.ID,
+		Reason: e.Reason,
+	}
+	told := make(map[core.SessionID]struct{})
+	for _, snap := range n.registry.MembersOfRoom(e.Room.ID) {
+		send(snap.Session.Signal(), msg)
+		told[snap.SID] = struct{}{}
+	}
+	if !e.Room.Public {
+		return
+	}
+	for _, snap := range n.registry.LobbySessions() {
+		if _, ok := told[snap.SID]; !ok {
+			send(snap.Session.Signal(), msg)
+		}
+	}
+}
+
+func (n *Notifier) broadcastRoom(roomID domain.RoomID, v any) {
+	for _, snap := range n.registry.MembersOfRoom(roomID) {
+		send(snap.Session.Signal(), v)
+	}
+}
+
+func (n *Notifier) sendTo(sids []core.SessionID, v any) {
+	for _, sid := range sids {
+		if sess, ok := n.registry.GetSession(sid); ok {
+			send(sess.Signal(), v)
+		}
+	}
+}
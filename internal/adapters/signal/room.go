@@ -1,49 +1,36 @@
 package signal
 
 import (
-	"encoding/json"
+	"errors"
+	"time"
 
+	"github.com/dkeye/Voice/internal/app"
+	"github.com/dkeye/Voice/internal/app/orch"
 	"github.com/dkeye/Voice/internal/core"
 	"github.com/dkeye/Voice/internal/domain"
+	"github.com/dkeye/Voice/internal/protocol"
 	"github.com/rs/zerolog/log"
 )
 
-func (ctl *SignalWSController) createRoom(
-	sid core.SessionID,
-	conn *WsSignalConn,
-	data []byte,
-) {
-	roomID, _, ok := ctl.Orch.Registry.RoomOf(sid)
+func (ctl *SignalWSController) createRoom(req *Request) {
+	roomID, _, ok := ctl.Orch.Registry.RoomOf(req.SID)
 	if ok {
 		if _, ok := ctl.Orch.Rooms.GetRoom(roomID); ok {
-			ctl.sendJSON(conn, map[string]any{
-				"type":  "error",
-				"error": "you alredy in room",
-			})
+			ctl.Fail(req, protocol.ErrAlreadyInRoom, "")
 			return
 		}
 	}
-	user, _ := ctl.Orch.Registry.GetOrCreateUser(sid)
+	user, _ := ctl.Orch.Registry.GetOrCreateUser(req.SID)
 	uid := user.ID
 
 	if !ctl.roomLimiter.Allow(uid) {
-		ctl.sendJSON(conn, map[string]any{
-			"type":  "error",
-			"error": "rate_limited",
-		})
+		ctl.Fail(req, protocol.ErrRateLimited, "")
 		return
 	}
-	type Payload struct {
-		Type string `json:"type"`
-		Name string `json:"name"`
-	}
-	var p Payload
-	if err := json.Unmarshal(data, &p); err != nil {
-		log.Error().Err(err).Str("module", "signal").Msg("bad join payload")
-		ctl.sendJSON(conn, map[string]any{
-			"type":  "error",
-			"error": "bad_payload",
-		})
+	var p protocol.CreateRoom
+	if err := req.Decode(&p); err != nil {
+		log.Error().Err(err).Str("module", "signal").Msg("bad create_room payload")
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
 		return
 	}
 	raw := p.Name
@@ -52,103 +39,276 @@ func (ctl *SignalWSController) createRoom(
 	}
 	name := domain.RoomName(raw)
 
-	room := ctl.Orch.Rooms.CreateRoom(name)
-	resp := struct {
-		Type string `json:"type"`
-		Room string `json:"room"`
-	}{
-		"room_created",
-		string(room.Room().ID),
+	switch p.Mode {
+	case "", domain.RoomModeConference, domain.RoomModeStage:
+	default:
+		ctl.Fail(req, protocol.ErrBadMode, string(p.Mode))
+		return
 	}
-	ctl.sendJSON(conn, resp)
+	switch p.Visibility {
+	case "", domain.RoomPrivate, domain.RoomPublic:
+	default:
+		ctl.Fail(req, protocol.ErrBadPayload, "visibility must be private or public")
+		return
+	}
+	if p.FloorMaxHold < 0 {
+		ctl.Fail(req, protocol.ErrBadPayload, "floor_max_hold must not be negative")
+		return
+	}
+
+	if p.Persistent {
+		if err := ctl.Orch.CheckPersistent(req.SID); err != nil {
+			ctl.FailErr(req, err)
+			return
+		}
+	}
+	if err := ctl.Orch.Authorize(req.SID, core.AccessCreate, "", name); err != nil {
+		ctl.failDenied(req, err)
+		return
+	}
+
+	var hash []byte
+	if p.Password != "" {
+		h, err := app.HashRoomPassword(p.Password)
+		if err != nil {
+			ctl.Fail(req, protocol.ErrBadPayload, err.Error())
+			return
+		}
+		hash = h
+	}
+
+	room := ctl.Orch.CreateRoom(name, domain.RoomSettings{
+		Mode:         p.Mode,
+		Visibility:   p.Visibility,
+		PasswordHash: hash,
+		InviteOnly:   p.InviteOnly,
+		MaxMembers:   p.MaxMembers,
+		Persistent:   p.Persistent,
+		FloorControl: p.FloorControl,
+		FloorMaxHold: time.Duration(p.FloorMaxHold) * time.Second,
+	})
+	room.GrantRole(uid, domain.RoleOwner)
+	ctl.Reply(req, &protocol.RoomCreated{
+		Header:       protocol.Header{Type: protocol.TypeRoomCreated},
+		Room:         room.Room().ID,
+		Mode:         room.Room().Settings.Mode,
+		Visibility:   room.Room().Settings.Visibility,
+		Locked:       room.Room().IsLocked(),
+		FloorControl: room.Room().Settings.FloorControl,
+		MaxMembers:   room.Room().Settings.MaxMembers,
+		Persistent:   room.Room().Settings.Persistent,
+	})
 }
 
-func (ctl *SignalWSController) handleJoin(
-	sid core.SessionID,
-	conn *WsSignalConn,
-	data []byte,
-) {
-	type joinPayload struct {
-		Type string `json:"type"`
-		Room string `json:"room"`
-		Name string `json:"name,omitempty"`
-	}
-	var p joinPayload
-	if err := json.Unmarshal(data, &p); err != nil {
+func (ctl *SignalWSController) handleJoin(req *Request) {
+	var p protocol.Join
+	if err := req.Decode(&p); err != nil {
 		log.Error().Err(err).Str("module", "signal").Msg("bad join payload")
-		ctl.sendJSON(conn, map[string]any{
-			"type":  "error",
-			"error": "bad_payload",
-		})
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
+		return
+	}
+	if p.Room == domain.EchoRoomID {
+		ctl.handleJoinEcho(req)
 		return
 	}
-	room, ok := ctl.Orch.Rooms.GetRoom(domain.RoomID(p.Room))
+	room, ok := ctl.Orch.Rooms.GetRoom(p.Room)
 	if !ok {
-		log.Error().Str("module", "signal").Str("room_id", p.Room).Msg("room is not exists")
-		ctl.sendJSON(conn, map[string]any{
-			"type":  "error",
-			"error": "room is not exists",
-		})
+		log.Error().Str("module", "signal").Str("room_id", string(p.Room)).Msg("room is not exists")
+		ctl.Fail(req, protocol.ErrRoomNotFound, "")
+		return
+	}
+
+	if err := ctl.Orch.Authorize(req.SID, core.AccessJoin, p.Room, room.Room().Name); err != nil {
+		ctl.failDenied(req, err)
+		return
+	}
+	if err := ctl.Orch.Admit(req.SID, room, p.Password, p.Invite); err != nil {
+		log.Warn().Err(err).Str("module", "signal").Str("sid", string(req.SID)).Str("room_id", string(p.Room)).Msg("join denied")
+		ctl.FailErr(req, err)
+		return
+	}
+
+	// Everything that can reject the join runs before anything changes on
+	// the user's behalf, so a rejected join leaves their other device and
+	// their name as they were.
+	if err := ctl.Orch.CheckJoin(req.SID, p.Room); err != nil {
+		ctl.failJoin(req, err)
 		return
 	}
 
 	if p.Name != "" {
-		ctl.Orch.Registry.UpdateUsername(sid, p.Name)
-		log.Info().Str("module", "signal").Str("sid", string(sid)).Str("name", p.Name).Msg("rename on join")
-	}
-
-	log.Info().Str("module", "signal").Str("sid", string(sid)).Str("room_id", string(p.Room)).Msg("join")
-	ctl.Orch.Join(sid, domain.RoomID(p.Room))
-	clientResp := struct {
-		Type     string           `json:"type"`
-		Room     domain.RoomID    `json:"room"`
-		RoomName domain.RoomName  `json:"room_name"`
-		Members  []core.MemberDTO `json:"members"`
-		Count    int              `json:"count"`
-	}{
-		Type:     "room_state",
+		if err := ctl.Orch.Rename(req.SID, p.Name); err != nil {
+			ctl.FailErr(req, err)
+			return
+		}
+		log.Info().Str("module", "signal").Str("sid", string(req.SID)).Str("name", p.Name).Msg("rename on join")
+	}
+
+	log.Info().Str("module", "signal").Str("sid", string(req.SID)).Str("room_id", string(p.Room)).Msg("join")
+	user, err := ctl.Orch.Registry.GetOrCreateUser(req.SID)
+	if err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	// A user is in a room once: joining from a second device moves the
+	// membership over from the first, which the rest of the room does not
+	// see as a leave and a join.
+	if other, ok := room.SessionOf(user.ID); ok && other != req.SID {
+		ctl.moveDevice(req, room, other)
+		return
+	}
+	if err := ctl.Orch.Join(req.SID, p.Room); err != nil {
+		ctl.failJoin(req, err)
+		return
+	}
+	// The last use of an invite may have gone to someone else since Admit;
+	// the member has not been announced yet, so it can quietly go again.
+	if err := ctl.Orch.RedeemInvite(req.SID, room, p.Invite); err != nil {
+		ctl.Orch.KickBySID(req.SID)
+		ctl.FailErr(req, err)
+		return
+	}
+	clientResp := ctl.roomState(room)
+	ctl.Reply(req, clientResp)
+
+	ctl.BroadcastFrom(req.SID, &protocol.MemberEvent{
+		Header: protocol.Header{Type: protocol.TypeMemberJoined},
+		User:   *user,
+	})
+}
+
+// moveDevice finishes a join that takes the membership over from other, the
+// user's session on another device. The old device is told it left.
+func (ctl *SignalWSController) moveDevice(req *Request, room core.RoomService, other core.SessionID) {
+	if err := ctl.Orch.Move(other, req.SID, room.Room().ID); err != nil {
+		ctl.failJoin(req, err)
+		return
+	}
+	if sess, ok := ctl.Orch.Registry.GetSession(other); ok {
+		if sig := sess.Signal(); sig != nil {
+			ctl.send(sig, protocol.NewLeft(protocol.LeftOtherDevice))
+		}
+	}
+	ctl.Reply(req, ctl.roomState(room))
+}
+
+// failJoin reports a failed join, with the ban details if the user is banned.
+func (ctl *SignalWSController) failJoin(req *Request, err error) {
+	var banned *orch.BanError
+	if errors.As(err, &banned) {
+		ctl.Fail(req, protocol.ErrBanned, banned.Error())
+		return
+	}
+	ctl.FailErr(req, err)
+}
+
+// failDenied reports an authorization failure with the reason the
+// Authorizer gave.
+func (ctl *SignalWSController) failDenied(req *Request, err error) {
+	var denied *orch.DeniedError
+	if errors.As(err, &denied) {
+		log.Info().Str("module", "signal").Str("sid", string(req.SID)).Str("reason", denied.Reason).Msg("access denied")
+		ctl.Fail(req, protocol.ErrAccessDenied, denied.Reason)
+		return
+	}
+	ctl.FailErr(req, err)
+}
+
+func (ctl *SignalWSController) handleCreateInvite(req *Request) {
+	var p protocol.CreateInvite
+	if err := req.Decode(&p); err != nil {
+		log.Error().Err(err).Str("module", "signal").Msg("bad create_invite payload")
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
+		return
+	}
+	if p.TTL < 0 || p.MaxUses < 0 {
+		ctl.Fail(req, protocol.ErrBadPayload, "ttl and max_uses must not be negative")
+		return
+	}
+	token, inv, err := ctl.Orch.CreateInvite(req.SID, time.Duration(p.TTL)*time.Second, p.MaxUses)
+	if err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	ctl.Reply(req, &protocol.Invite{
+		Header:    protocol.Header{Type: protocol.TypeInvite},
+		Room:      inv.Room,
+		Token:     token,
+		ExpiresAt: inv.Expires.Unix(),
+		MaxUses:   inv.MaxUses,
+	})
+}
+
+// roomState is the full snapshot a member gets on join and on resume.
+func (ctl *SignalWSController) roomState(room core.RoomService) *protocol.RoomState {
+	state := &protocol.RoomState{
+		Header:   protocol.Header{Type: protocol.TypeRoomState},
 		Room:     room.Room().ID,
 		RoomName: room.Room().Name,
+		Mode:     room.Room().Settings.Mode,
 		Members:  room.MembersSnapshot(),
 		Count:    room.MemberCount(),
+		Chat:     room.ChatHistory(),
 	}
-	ctl.sendJSON(conn, clientResp)
-
-	user, _ := ctl.Orch.Registry.GetOrCreateUser(sid)
+	if room.Room().IsStage() {
+		state.HandQueue = room.HandQueue()
+	}
+	if floor, ok := ctl.Orch.FloorState(room); ok {
+		state.Floor = floorChangedMessage(floor)
+	}
+	return state
+}
 
-	broadcastResp := struct {
-		Type string      `json:"type"`
-		User domain.User `json:"user"`
-	}{
-		Type: "member_joined",
-		User: *user,
+// handleJoinEcho starts the loopback mic check. The session stays out of any
+// room, so it neither sends nor receives room broadcasts.
+func (ctl *SignalWSController) handleJoinEcho(req *Request) {
+	if err := ctl.Orch.JoinEcho(req.SID); err != nil {
+		ctl.FailErr(req, err)
+		return
 	}
-	ctl.BroadcastFrom(sid, broadcastResp)
+	log.Info().Str("module", "signal").Str("sid", string(req.SID)).Msg("join echo")
+
+	user, _ := ctl.Orch.Registry.GetOrCreateUser(req.SID)
+	ctl.Reply(req, &protocol.RoomState{
+		Header:   protocol.Header{Type: protocol.TypeRoomState},
+		Room:     domain.EchoRoomID,
+		RoomName: "Echo test",
+		Mode:     "echo",
+		Members:  []core.MemberDTO{{ID: user.ID, Username: user.Username}},
+		Count:    1,
+		DelayMS:  ctl.Orch.EchoDelay.Milliseconds(),
+	})
 }
 
 // handleLeave — выход из текущей комнаты, соединение при этом не рвётся.
-func (ctl *SignalWSController) handleLeave(
-	sid core.SessionID,
-	conn *WsSignalConn,
-) {
+func (ctl *SignalWSController) handleLeave(req *Request) {
+	ctl.leave(req.SID)
+	ctl.Reply(req, protocol.Simple(protocol.TypeLeft))
+}
+
+// leave removes sid from its room and tells the remaining members.
+// It also runs when the signal connection goes away.
+func (ctl *SignalWSController) leave(sid core.SessionID) {
 	log.Info().Str("module", "signal").Str("sid", string(sid)).Msg("leave")
 	roomID, _, ok := ctl.Orch.Registry.RoomOf(sid)
 
 	ctl.Orch.KickBySID(sid)
-	ctl.sendJSON(conn, map[string]any{
-		"type": "left",
-	})
 
 	if ok {
-		user, _ := ctl.Orch.Registry.GetOrCreateUser(sid)
-
-		broadcastResp := struct {
-			Type string      `json:"type"`
-			User domain.User `json:"user"`
-		}{
-			Type: "member_left",
-			User: *user,
-		}
-		ctl.BroadcastRoom(roomID, broadcastResp)
+		ctl.announceLeft(roomID, sid)
+	}
+}
+
+// announceLeft tells the rest of the room that sid is gone.
+func (ctl *SignalWSController) announceLeft(roomID domain.RoomID, sid core.SessionID) {
+	if user, err := ctl.Orch.Registry.GetOrCreateUser(sid); err == nil {
+		ctl.BroadcastRoom(roomID, &protocol.MemberEvent{
+			Header: protocol.Header{Type: protocol.TypeMemberLeft},
+			User:   *user,
+		})
+	}
+
+	if room, ok := ctl.Orch.Rooms.GetRoom(roomID); ok && room.Room().IsStage() {
+		ctl.broadcastHandQueue(room)
 	}
 }
@@ -11,7 +11,9 @@ import (
 	"github.com/dkeye/Voice/internal/config"
 	"github.com/dkeye/Voice/internal/core"
 	"github.com/dkeye/Voice/internal/domain"
+	"github.com/dkeye/Voice/internal/protocol"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
 )
@@ -22,12 +24,25 @@ type SignalWSController struct {
 	Orch        *orch.Orchestrator
 	upgrader    websocket.Upgrader
 	roomLimiter *RoomRateLimiter
+	handlers    *Handlers
+
+	readLimit   int64
+	pingPeriod  time.Duration
+	resumeGrace time.Duration
+	// singleDevice closes a client's older sessions when it connects again.
+	singleDevice bool
 }
 
-func NewSignalWSController(orch orch.Orchestrator, cfg *config.Config) *SignalWSController {
+func NewSignalWSController(orch orch.Orchestrator, cfg *config.Config, handlers *Handlers) *SignalWSController {
 	return &SignalWSController{
-		Orch: &orch,
+		Orch:         &orch,
+		handlers:     handlers,
+		readLimit:    cfg.ReadLimit,
+		pingPeriod:   cfg.PingPeriod,
+		resumeGrace:  cfg.ResumeGrace,
+		singleDevice: cfg.SingleDevice,
 		upgrader: websocket.Upgrader{
+			Subprotocols: protocol.Subprotocols(),
 			CheckOrigin: func(r *http.Request) bool {
 				origin := r.Header.Get("Origin")
 				return origin == cfg.Origin
@@ -38,8 +53,9 @@ func NewSignalWSController(orch orch.Orchestrator, cfg *config.Config) *SignalWS
 }
 
 type WsSignalConn struct {
-	conn *websocket.Conn
-	send chan core.Frame
+	conn  *websocket.Conn
+	send  chan core.Frame
+	codec protocol.Codec
 
 	mu     sync.RWMutex
 	closed bool
@@ -59,6 +75,29 @@ func (c *WsSignalConn) TrySend(f core.Frame) error {
 	return nil
 }
 
+func (c *WsSignalConn) IsClosed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.closed
+}
+
+// Codec returns the message encoding negotiated for this connection.
+func (c *WsSignalConn) Codec() protocol.Codec { return c.codec }
+
+func (c *WsSignalConn) messageType() int {
+	if c.codec.Binary() {
+		return websocket.BinaryMessage
+	}
+	return websocket.TextMessage
+}
+
+// CloseWith sends a close frame carrying code and reason, then closes.
+func (c *WsSignalConn) CloseWith(code int, reason string) {
+	msg := websocket.FormatCloseMessage(code, reason)
+	_ = c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+	c.Close()
+}
+
 func (c *WsSignalConn) Close() {
 	c.mu.Lock()
 	if c.closed {
@@ -73,19 +112,19 @@ func (c *WsSignalConn) Close() {
 
 func (ctl *SignalWSController) BroadcastFrom(sid core.SessionID, v any) {
 	for _, roomMate := range ctl.Orch.Registry.RoomMates(sid) {
-		ctl.sendJSON(roomMate.Session.Signal(), v)
+		ctl.send(roomMate.Session.Signal(), v)
 	}
 }
 
 func (ctl *SignalWSController) BroadcastRoom(roomID domain.RoomID, v any) {
 	for _, snap := range ctl.Orch.Registry.MembersOfRoom(roomID) {
-		ctl.sendJSON(snap.Session.Signal(), v)
+		ctl.send(snap.Session.Signal(), v)
 	}
 }
 
 func (ctl *SignalWSController) HandleSignal(ctx context.Context, c *gin.Context) {
-	sid := core.SessionID(c.GetString("client_token"))
-	log.Info().Str("module", "signal").Str("sid", string(sid)).Msg("new WS connection")
+	cid := core.ClientID(c.GetString("client_token"))
+	log.Info().Str("module", "signal").Str("client", string(cid)).Msg("new WS connection")
 
 	ws, err := ctl.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -94,16 +133,97 @@ func (ctl *SignalWSController) HandleSignal(ctx context.Context, c *gin.Context)
 	}
 
 	conn := &WsSignalConn{
-		conn: ws,
-		send: make(chan core.Frame, 32),
+		conn:  ws,
+		send:  make(chan core.Frame, 32),
+		codec: protocol.CodecFor(ws.Subprotocol()),
 	}
+	log.Info().Str("module", "signal").Str("client", string(cid)).Str("codec", conn.codec.Subprotocol()).Msg("codec negotiated")
 
-	user, _ := ctl.Orch.Registry.GetOrCreateUser(sid)
-	meta := domain.NewMember(user)
-	sess := core.NewMemberSession(meta).UpdateSignal(conn)
 	ctx, cancel := context.WithCancel(ctx)
-	ctl.Orch.Registry.BindSignal(sid, sess, cancel)
+	sid, token, resumed := ctl.resume(cid, c.Query("resume"), conn, cancel)
+	if !resumed {
+		if ctl.singleDevice {
+			ctl.evictDevices(cid)
+		}
+		sid = core.SessionID(uuid.NewString())
+		user, err := ctl.Orch.Registry.ClientUser(cid)
+		if err != nil {
+			log.Error().Err(err).Str("module", "signal").Str("client", string(cid)).Msg("client user")
+			cancel()
+			conn.Close()
+			return
+		}
+		meta := domain.NewMember(user)
+		meta.ClientIP = c.ClientIP()
+		sess := core.NewMemberSession(meta).UpdateSignal(conn)
+		if token, err = ctl.Orch.BindSession(cid, sid, sess, cancel); err != nil {
+			cancel()
+			conn.CloseWith(protocol.CloseServerBusy, "server busy")
+			return
+		}
+	}
 
 	go ctl.writePump(ctx, conn)
 	go ctl.readPump(ctx, sid, conn)
+	ctl.send(conn, protocol.NewHello(token, ctl.resumeGrace, resumed))
+	if resumed {
+		ctl.resync(sid, conn)
+	}
+}
+
+// resume takes over one of cid's sessions when the client presents a valid
+// resume token. Room membership, relays and subscriptions stay as they are;
+// only the signal connection is swapped.
+func (ctl *SignalWSController) resume(cid core.ClientID, token string, conn *WsSignalConn, cancel context.CancelFunc) (core.SessionID, string, bool) {
+	if token == "" {
+		return "", "", false
+	}
+	sid, prev, next, ok := ctl.Orch.Registry.Resume(cid, token, conn, cancel)
+	if !ok {
+		log.Warn().Str("module", "signal").Str("client", string(cid)).Msg("resume rejected")
+		return "", "", false
+	}
+	if prev != nil {
+		prev.Close()
+	}
+	log.Info().Str("module", "signal").Str("sid", string(sid)).Msg("session resumed")
+	return sid, next, true
+}
+
+// evictDevices closes every existing session of cid. It runs before a new
+// device connects when the server allows one device per client.
+func (ctl *SignalWSController) evictDevices(cid core.ClientID) {
+	for _, sid := range ctl.Orch.Registry.SessionsOf(cid) {
+		log.Info().Str("module", "signal").Str("sid", string(sid)).Msg("evict older device")
+		sess, ok := ctl.Orch.Registry.GetSession(sid)
+		ctl.leave(sid)
+		ctl.Orch.Registry.Unbind(sid)
+		if ok {
+			if c, ok := sess.Signal().(*WsSignalConn); ok {
+				c.CloseWith(protocol.CloseReplaced, "replaced by another device")
+			}
+		}
+	}
+}
+
+// resync brings a resumed client up to date with the room it is still in,
+// since broadcasts sent while it was away are lost.
+func (ctl *SignalWSController) resync(sid core.SessionID, conn *WsSignalConn) {
+	roomID, _, ok := ctl.Orch.Registry.RoomOf(sid)
+	if !ok {
+		return
+	}
+	if room, ok := ctl.Orch.Rooms.GetRoom(roomID); ok {
+		ctl.send(conn, ctl.roomState(room))
+	}
+}
+
+// disconnect runs when a connection's read side ends. The session is kept
+// for the resume grace period; if no new connection takes it over by then,
+// the member leaves as if it had sent leave.
+func (ctl *SignalWSController) disconnect(sid core.SessionID, conn *WsSignalConn) {
+	ctl.Orch.Registry.Detach(sid, conn, ctl.resumeGrace, func() {
+		ctl.leave(sid)
+		ctl.Orch.Registry.Unbind(sid)
+	})
 }
@@ -0,0 +1,94 @@
+package signal
+
+import (
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/dkeye/Voice/internal/protocol"
+	"github.com/rs/zerolog/log"
+)
+
+func (ctl *SignalWSController) handleRaiseHand(req *Request) {
+	room, err := ctl.Orch.RaiseHand(req.SID)
+	if err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	log.Info().Str("module", "signal").Str("sid", string(req.SID)).Msg("raise hand")
+	ctl.Ack(req)
+	ctl.broadcastHandQueue(room)
+}
+
+func (ctl *SignalWSController) handleLowerHand(req *Request) {
+	room, err := ctl.Orch.LowerHand(req.SID)
+	if err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	log.Info().Str("module", "signal").Str("sid", string(req.SID)).Msg("lower hand")
+	ctl.Ack(req)
+	ctl.broadcastHandQueue(room)
+}
+
+func (ctl *SignalWSController) handleStageRole(req *Request, role domain.StageRole) {
+	var p protocol.Target
+	if err := req.Decode(&p); err != nil {
+		log.Error().Err(err).Str("module", "signal").Msg("bad stage payload")
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
+		return
+	}
+	if p.Role != "" {
+		ctl.handleRoomRole(req, p)
+		return
+	}
+
+	var (
+		room      core.RoomService
+		targetSID core.SessionID
+		err       error
+	)
+	if role == domain.StageSpeaker {
+		room, targetSID, err = ctl.Orch.Promote(req.SID, p.User)
+	} else {
+		room, targetSID, err = ctl.Orch.Demote(req.SID, p.User)
+	}
+	if err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	ctl.Ack(req)
+
+	ms, ok := room.Member(targetSID)
+	if !ok {
+		return
+	}
+	member, ok := room.MemberDTO(targetSID)
+	if !ok {
+		return
+	}
+	// The hint goes first so the client knows which direction to answer
+	// the server's re-offer with.
+	direction := "recvonly"
+	if role == domain.StageSpeaker {
+		direction = "sendrecv"
+	}
+	ctl.send(ms.Signal(), &protocol.StageRole{
+		Header:    protocol.Header{Type: protocol.TypeStageRole},
+		Role:      role,
+		Direction: direction,
+	})
+	ctl.Orch.Renegotiate(targetSID)
+
+	ctl.BroadcastRoom(room.Room().ID, &protocol.StageChanged{
+		Header: protocol.Header{Type: protocol.TypeStageChanged},
+		User:   member.User(),
+		Role:   role,
+	})
+	ctl.broadcastHandQueue(room)
+}
+
+func (ctl *SignalWSController) broadcastHandQueue(room core.RoomService) {
+	ctl.BroadcastRoom(room.Room().ID, &protocol.HandQueue{
+		Header: protocol.Header{Type: protocol.TypeHandQueue},
+		Queue:  room.HandQueue(),
+	})
+}
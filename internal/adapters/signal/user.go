@@ -1,81 +1,73 @@
 package signal
 
 import (
-	"encoding/json"
-
-	"github.com/dkeye/Voice/internal/core"
-	"github.com/dkeye/Voice/internal/domain"
+	"github.com/dkeye/Voice/internal/protocol"
 	"github.com/rs/zerolog/log"
 )
 
-func (ctl *SignalWSController) handleRename(
-	sid core.SessionID,
-	conn *WsSignalConn,
-	data []byte,
-) {
-	type renamePayload struct {
-		Type string `json:"type"`
-		Name string `json:"name"`
-	}
-	var p renamePayload
-	if err := json.Unmarshal(data, &p); err != nil {
+func (ctl *SignalWSController) handleRename(req *Request) {
+	var p protocol.Rename
+	if err := req.Decode(&p); err != nil {
 		log.Error().Err(err).Str("module", "signal").Msg("bad rename payload")
-		ctl.sendJSON(conn, map[string]any{
-			"type":  "error",
-			"error": "bad_payload",
-		})
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
 		return
 	}
-	if p.Name == "" {
-		ctl.sendJSON(conn, map[string]any{
-			"type":  "error",
-			"error": "empty name",
-		})
+
+	log.Info().Str("module", "signal").Str("sid", string(req.SID)).Str("name", p.Name).Msg("rename")
+	if err := ctl.Orch.Rename(req.SID, p.Name); err != nil {
+		ctl.FailErr(req, err)
 		return
 	}
+	ctl.handleWhoAmI(req)
+}
 
-	log.Info().Str("module", "signal").Str("sid", string(sid)).Str("name", p.Name).Msg("rename")
-	ctl.Orch.Registry.UpdateUsername(sid, p.Name)
-	if err := ctl.Orch.Registry.UpdateUsername(sid, p.Name); err != nil {
-		ctl.sendJSON(conn, map[string]any{
-			"type":  "error",
-			"error": "invalid_name",
-		})
+func (ctl *SignalWSController) handleSetPrefs(req *Request) {
+	var p protocol.SetPrefs
+	if err := req.Decode(&p); err != nil {
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
 		return
 	}
-	ctl.handleWhoAmI(sid, conn)
-	user, _ := ctl.Orch.Registry.GetOrCreateUser(sid)
-
-	broadcastResp := struct {
-		Type string      `json:"type"`
-		User domain.User `json:"user"`
-	}{
-		Type: "member_updated",
-		User: *user,
+	if err := ctl.Orch.Registry.UpdatePrefs(req.SID, p.Prefs); err != nil {
+		ctl.FailErr(req, err)
+		return
 	}
-	ctl.BroadcastFrom(sid, broadcastResp)
+	ctl.Ack(req)
 }
 
-func (ctl *SignalWSController) handleWhoAmI(
-	sid core.SessionID,
-	conn *WsSignalConn,
-) {
-	user, _ := ctl.Orch.Registry.GetOrCreateUser(sid)
+func (ctl *SignalWSController) handleWhoAmI(req *Request) {
+	prof, _ := ctl.Orch.Registry.Profile(req.SID)
 
-	resp := struct {
-		Type     string          `json:"type"`
-		Username string          `json:"username"`
-		Room     domain.RoomID   `json:"room,omitempty"`
-		RoomName domain.RoomName `json:"room_name,omitempty"`
-	}{
-		Type:     "whoami",
-		Username: user.Username,
+	resp := &protocol.WhoAmI{
+		Header:    protocol.Header{Type: protocol.TypeWhoAmI},
+		Username:  prof.Username,
+		Login:     prof.Login,
+		Roles:     prof.Roles,
+		Prefs:     prof.Prefs,
+		CreatedAt: prof.CreatedAt,
 	}
-	if RoomID, _, ok := ctl.Orch.Registry.RoomOf(sid); ok {
+	if RoomID, _, ok := ctl.Orch.Registry.RoomOf(req.SID); ok {
 		if room, ok := ctl.Orch.Rooms.GetRoom(RoomID); ok {
 			resp.RoomName = room.Room().Name
 			resp.Room = RoomID
 		}
 	}
-	ctl.sendJSON(conn, resp)
+	ctl.Reply(req, resp)
+}
+
+func (ctl *SignalWSController) handleDeafen(req *Request, deafened bool) {
+	if _, err := ctl.Orch.SetDeafened(req.SID, deafened); err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	log.Info().Str("module", "signal").Str("sid", string(req.SID)).Bool("deafened", deafened).Msg("deafen")
+	ctl.Ack(req)
+}
+
+func (ctl *SignalWSController) handleMute(req *Request, muted bool) {
+	if err := ctl.Orch.SetMuted(req.SID, muted); err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	log.Info().Str("module", "signal").Str("sid", string(req.SID)).Bool("muted", muted).Msg("mute")
+	ctl.Ack(req)
 }
@@ -2,22 +2,33 @@ package signal
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/dkeye/Voice/internal/adapters/rtc"
 	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/protocol"
 	"github.com/pion/webrtc/v4"
 	"github.com/rs/zerolog/log"
 )
 
-func (ctl *SignalWSController) sendCandidate(c *WsSignalConn, ci webrtc.ICECandidateInit) {
-	resp := struct {
-		Type          string `json:"type"`
-		Candidate     string `json:"candidate"`
-		SDPMid        string `json:"sdpMid,omitempty"`
-		SDPMLineIndex uint16 `json:"sdpMLineIndex,omitempty"`
-	}{
-		Type:      "candidate",
+// signalOf returns the current signal connection of sid. Media callbacks
+// resolve it when they send, since a resume swaps it under a live
+// PeerConnection.
+func (ctl *SignalWSController) signalOf(sid core.SessionID) (core.SignalConnection, bool) {
+	sess, ok := ctl.Orch.Registry.GetSession(sid)
+	if !ok {
+		return nil, false
+	}
+	sig := sess.Signal()
+	return sig, sig != nil
+}
+
+func (ctl *SignalWSController) sendCandidate(sid core.SessionID, ci webrtc.ICECandidateInit) {
+	c, ok := ctl.signalOf(sid)
+	if !ok {
+		return
+	}
+	resp := &protocol.Candidate{
+		Header:    protocol.Header{Type: protocol.TypeCandidate},
 		Candidate: ci.Candidate,
 	}
 	if ci.SDPMid != nil {
@@ -26,36 +37,43 @@ func (ctl *SignalWSController) sendCandidate(c *WsSignalConn, ci webrtc.ICECandi
 	if ci.SDPMLineIndex != nil {
 		resp.SDPMLineIndex = *ci.SDPMLineIndex
 	}
-	ctl.sendJSON(c, resp)
+	ctl.send(c, resp)
 }
 
-func (ctl *SignalWSController) handleOffer(
-	sid core.SessionID,
-	conn *WsSignalConn,
-	data []byte,
-) {
-	type offerPayload struct {
-		Type string `json:"type"`
-		SDP  string `json:"sdp"`
-	}
-	var p offerPayload
-	if err := json.Unmarshal(data, &p); err != nil {
+func (ctl *SignalWSController) handleOffer(req *Request) {
+	var p protocol.SDP
+	if err := req.Decode(&p); err != nil {
 		log.Error().Err(err).Str("module", "signal").Msg("bad offer payload")
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
+		return
+	}
+	sid := req.SID
+	if err := ctl.Orch.AdmitMedia(sid); err != nil {
+		ctl.FailErr(req, err)
 		return
 	}
+	// An offer on a live connection renegotiates it; a second
+	// PeerConnection would leave the first one to tear the session down.
+	if sess, ok := ctl.Orch.Registry.GetSession(sid); ok {
+		if mc := sess.Media(); mc != nil && !mc.IsClosed() {
+			ctl.answerOffer(req, mc, p.SDP)
+			return
+		}
+	}
 
 	cfg := rtc.DefaultWebRTCConfig()
 	wc, err := rtc.NewWebRTCConnection(cfg, sid)
 	if err != nil {
 		log.Error().Err(err).Str("module", "signal").Msg("webrtc new pc")
+		ctl.Fail(req, protocol.ErrWebRTCFailed, err.Error())
 		return
 	}
 	wc.OnNegotiationNeeded(func() {
-		ctl.handleNegotiationNeeded(sid, conn, wc)
+		ctl.handleNegotiationNeeded(sid, wc)
 	})
 
 	wc.OnICECandidate(func(ci webrtc.ICECandidateInit) {
-		ctl.sendCandidate(conn, ci)
+		ctl.sendCandidate(sid, ci)
 	})
 
 	ctl.Orch.BindMediaHandlers(wc, sid)
@@ -63,6 +81,7 @@ func (ctl *SignalWSController) handleOffer(
 	if err = wc.Start(context.Background()); err != nil {
 		log.Error().Err(err).Str("module", "signal").Msg("webrtc start")
 		wc.Close()
+		ctl.Fail(req, protocol.ErrWebRTCFailed, err.Error())
 		return
 	}
 
@@ -74,6 +93,7 @@ func (ctl *SignalWSController) handleOffer(
 	if err = wc.ApplyOffer(offer); err != nil {
 		log.Error().Err(err).Str("module", "signal").Msg("webrtc apply offer")
 		wc.Close()
+		ctl.Fail(req, protocol.ErrWebRTCFailed, err.Error())
 		return
 	}
 
@@ -81,6 +101,7 @@ func (ctl *SignalWSController) handleOffer(
 	if err != nil {
 		log.Error().Err(err).Str("module", "signal").Msg("webrtc create/set answer")
 		wc.Close()
+		ctl.Fail(req, protocol.ErrWebRTCFailed, err.Error())
 		return
 	}
 
@@ -89,34 +110,49 @@ func (ctl *SignalWSController) handleOffer(
 		ctl.Orch.OnMediaReady(sid)
 	}
 
-	ctl.sendJSON(conn, map[string]string{
-		"type": "answer",
-		"sdp":  answer.SDP,
+	ctl.Reply(req, &protocol.SDP{
+		Header: protocol.Header{Type: protocol.TypeAnswer},
+		SDP:    answer.SDP,
 	})
 }
 
-func (ctl *SignalWSController) handleAnswer(
-	sid core.SessionID,
-	_ *WsSignalConn,
-	data []byte,
-) {
-	type answerPayload struct {
-		Type string `json:"type"`
-		SDP  string `json:"sdp"`
-	}
-	var p answerPayload
-	if err := json.Unmarshal(data, &p); err != nil {
+// answerOffer answers a client renegotiation on an established connection.
+func (ctl *SignalWSController) answerOffer(req *Request, mc core.MediaConnection, sdp string) {
+	offer := webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: sdp}
+	if err := mc.ApplyOffer(offer); err != nil {
+		log.Error().Err(err).Str("module", "signal").Str("sid", string(req.SID)).Msg("webrtc apply renegotiation offer")
+		ctl.Fail(req, protocol.ErrWebRTCFailed, err.Error())
+		return
+	}
+	answer, err := mc.CreateAndSetAnswer()
+	if err != nil {
+		log.Error().Err(err).Str("module", "signal").Str("sid", string(req.SID)).Msg("webrtc renegotiation answer")
+		ctl.Fail(req, protocol.ErrWebRTCFailed, err.Error())
+		return
+	}
+	ctl.Reply(req, &protocol.SDP{
+		Header: protocol.Header{Type: protocol.TypeAnswer},
+		SDP:    answer.SDP,
+	})
+}
+
+func (ctl *SignalWSController) handleAnswer(req *Request) {
+	var p protocol.SDP
+	if err := req.Decode(&p); err != nil {
 		log.Error().Err(err).Msg("bad answer payload")
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
 		return
 	}
 
-	sess, ok := ctl.Orch.Registry.GetSession(sid)
+	sess, ok := ctl.Orch.Registry.GetSession(req.SID)
 	if !ok {
+		ctl.Fail(req, protocol.ErrNoSession, "")
 		return
 	}
 
 	mc := sess.Media()
 	if mc == nil {
+		ctl.Fail(req, protocol.ErrNoMedia, "")
 		return
 	}
 
@@ -127,28 +163,23 @@ func (ctl *SignalWSController) handleAnswer(
 
 	if err := mc.ApplyAnswer(answer); err != nil {
 		log.Error().Err(err).Msg("set remote answer")
+		ctl.Fail(req, protocol.ErrWebRTCFailed, err.Error())
 		return
 	}
+	ctl.Ack(req)
 }
 
-func (ctl *SignalWSController) handleCandidate(
-	sid core.SessionID,
-	_ *WsSignalConn,
-	data []byte,
-) {
-	type candidatePayload struct {
-		Type          string `json:"type"`
-		Candidate     string `json:"candidate"`
-		SDPMid        string `json:"sdpMid"`
-		SDPMLineIndex uint16 `json:"sdpMLineIndex"`
-	}
-	var p candidatePayload
-	if err := json.Unmarshal(data, &p); err != nil {
+func (ctl *SignalWSController) handleCandidate(req *Request) {
+	var p protocol.Candidate
+	if err := req.Decode(&p); err != nil {
 		log.Error().Err(err).Str("module", "signal").Msg("bad candidate payload")
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
 		return
 	}
+	sid := req.SID
 	if p.Candidate == "" {
 		log.Debug().Str("module", "signal").Str("sid", string(sid)).Msg("end-of-candidates")
+		ctl.Ack(req)
 		return
 	}
 
@@ -163,31 +194,36 @@ func (ctl *SignalWSController) handleCandidate(
 	sess, ok := ctl.Orch.Registry.GetSession(sid)
 	if !ok {
 		log.Warn().Str("module", "signal").Str("sid", string(sid)).Msg("candidate: no session for")
+		ctl.Fail(req, protocol.ErrNoSession, "")
 		return
 	}
 	mc := sess.Media()
 	if mc == nil {
 		log.Warn().Str("module", "signal").Str("sid", string(sid)).Msg("candidate: no media connection for")
+		ctl.Fail(req, protocol.ErrNoMedia, "")
 		return
 	}
 	if err := mc.AddICECandidate(cand); err != nil {
 		log.Error().Err(err).Str("module", "signal").Msg("add ice candidate")
+		ctl.Fail(req, protocol.ErrWebRTCFailed, err.Error())
+		return
 	}
+	ctl.Ack(req)
 }
 
-func (ctl *SignalWSController) handleNegotiationNeeded(
-	sid core.SessionID,
-	conn *WsSignalConn,
-	mc core.MediaConnection,
-) {
+func (ctl *SignalWSController) handleNegotiationNeeded(sid core.SessionID, mc core.MediaConnection) {
+	conn, ok := ctl.signalOf(sid)
+	if !ok {
+		return
+	}
 	offer, err := mc.CreateAndSetOffer()
 	if err != nil {
 		log.Error().Err(err).Msg("negotiation offer failed")
 		return
 	}
 
-	ctl.sendJSON(conn, map[string]string{
-		"type": "offer",
-		"sdp":  offer.SDP,
+	ctl.send(conn, &protocol.SDP{
+		Header: protocol.Header{Type: protocol.TypeOffer},
+		SDP:    offer.SDP,
 	})
 }
@@ -0,0 +1,100 @@
+package signal
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/dkeye/Voice/internal/app"
+	"github.com/dkeye/Voice/internal/app/orch"
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/dkeye/Voice/internal/protocol"
+	"github.com/pion/webrtc/v4"
+)
+
+// recordingConn is a core.SignalConnection that keeps what it was sent.
+type recordingConn struct {
+	mu     sync.Mutex
+	frames []core.Frame
+	closed bool
+}
+
+func (c *recordingConn) TrySend(f core.Frame) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.frames = append(c.frames, f)
+	return nil
+}
+
+func (c *recordingConn) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+}
+
+func (c *recordingConn) IsClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// types lists the message types sent on c.
+func (c *recordingConn) types(t *testing.T) []protocol.Type {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []protocol.Type
+	for _, f := range c.frames {
+		var h protocol.Header
+		if err := json.Unmarshal(f, &h); err != nil {
+			t.Fatalf("decode frame %s: %v", f, err)
+		}
+		out = append(out, h.Type)
+	}
+	return out
+}
+
+// offeringMedia is a core.MediaConnection that only makes offers.
+type offeringMedia struct {
+	core.MediaConnection
+}
+
+func (offeringMedia) CreateAndSetOffer() (*webrtc.SessionDescription, error) {
+	return &webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: "v=0"}, nil
+}
+
+func TestMediaCallbacksFollowResumedSignal(t *testing.T) {
+	reg := app.NewRegistry()
+	ctl := &SignalWSController{Orch: &orch.Orchestrator{Registry: reg}}
+
+	user, err := reg.ClientUser("client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := &recordingConn{}
+	sess := core.NewMemberSession(domain.NewMember(user)).UpdateSignal(old)
+	token, ok := reg.BindSignal("client", "s1", sess, func() {}, 0)
+	if !ok {
+		t.Fatal("BindSignal failed")
+	}
+	mc := offeringMedia{}
+	sess.UpdateMedia(mc)
+
+	old.Close()
+	fresh := &recordingConn{}
+	if _, _, _, ok := reg.Resume("client", token, fresh, func() {}); !ok {
+		t.Fatal("Resume failed")
+	}
+
+	ctl.handleNegotiationNeeded("s1", mc)
+	ctl.sendCandidate("s1", webrtc.ICECandidateInit{Candidate: "candidate:1"})
+
+	if got := old.types(t); len(got) != 0 {
+		t.Fatalf("closed signal got %v", got)
+	}
+	got := fresh.types(t)
+	if len(got) != 2 || got[0] != protocol.TypeOffer || got[1] != protocol.TypeCandidate {
+		t.Fatalf("resumed signal got %v, want [%s %s]", got, protocol.TypeOffer, protocol.TypeCandidate)
+	}
+}
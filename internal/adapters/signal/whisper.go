@@ -0,0 +1,46 @@
+package signal
+
+import (
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/protocol"
+	"github.com/rs/zerolog/log"
+)
+
+func whisperMessage(e core.WhisperChanged) *protocol.Whisper {
+	t := protocol.TypeWhisperEnded
+	switch {
+	case e.Updated:
+		t = protocol.TypeWhisperUpdated
+	case e.Active:
+		t = protocol.TypeWhisperStarted
+	}
+	return &protocol.Whisper{
+		Header:  protocol.Header{Type: t},
+		From:    e.From,
+		Targets: e.Targets,
+	}
+}
+
+func (ctl *SignalWSController) handleWhisperStart(req *Request) {
+	var p protocol.WhisperStart
+	if err := req.Decode(&p); err != nil {
+		log.Error().Err(err).Str("module", "signal").Msg("bad whisper payload")
+		ctl.Fail(req, protocol.ErrBadPayload, err.Error())
+		return
+	}
+	if err := ctl.Orch.StartWhisper(req.SID, p.Users); err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	log.Info().Str("module", "signal").Str("sid", string(req.SID)).Int("targets", len(p.Users)).Msg("whisper start")
+	ctl.Ack(req)
+}
+
+func (ctl *SignalWSController) handleWhisperStop(req *Request) {
+	if err := ctl.Orch.EndWhisper(req.SID); err != nil {
+		ctl.FailErr(req, err)
+		return
+	}
+	log.Info().Str("module", "signal").Str("sid", string(req.SID)).Msg("whisper stop")
+	ctl.Ack(req)
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/dkeye/Voice/internal/core"
+)
+
+// FileInviteStore keeps invite use counts in one JSON file, keyed by invite
+// ID. Like FileRoomStore it rewrites the whole file on every change.
+type FileInviteStore struct {
+	path string
+
+	mu   sync.Mutex
+	uses map[string]core.InviteUses
+}
+
+// NewFileInviteStore opens the store at path; a missing file is an empty store.
+func NewFileInviteStore(path string) (*FileInviteStore, error) {
+	s := &FileInviteStore{path: path, uses: make(map[string]core.InviteUses)}
+	if err := readJSON(path, &s.uses); err != nil {
+		return nil, fmt.Errorf("invite store: %w", err)
+	}
+	return s, nil
+}
+
+func (s *FileInviteStore) LoadInviteUses() ([]core.InviteUses, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]core.InviteUses, 0, len(s.uses))
+	for _, u := range s.uses {
+		out = append(out, u)
+	}
+	return out, nil
+}
+
+func (s *FileInviteStore) SaveInviteUses(u core.InviteUses) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.uses[u.ID] = u
+	return writeJSON(s.path, s.uses)
+}
+
+func (s *FileInviteStore) DeleteInviteUses(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.uses[id]; !ok {
+		return nil
+	}
+	delete(s.uses, id)
+	return writeJSON(s.path, s.uses)
+}
@@ -0,0 +1,107 @@
+// Package store holds the storage backends behind the core store interfaces.
+package store
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+)
+
+// FileRoomStore keeps room records in one JSON file. Every change rewrites
+// the file through a temporary file and a rename, so a crash leaves either
+// the old or the new contents.
+type FileRoomStore struct {
+	path string
+
+	mu    sync.Mutex
+	rooms map[domain.RoomID]core.RoomRecord
+}
+
+// NewFileRoomStore opens the store at path; a missing file is an empty store.
+func NewFileRoomStore(path string) (*FileRoomStore, error) {
+	s := &FileRoomStore{path: path, rooms: make(map[domain.RoomID]core.RoomRecord)}
+	var recs []core.RoomRecord
+	if err := readJSON(path, &recs); err != nil {
+		return nil, fmt.Errorf("room store: %w", err)
+	}
+	for _, rec := range recs {
+		s.rooms[rec.ID] = rec
+	}
+	return s, nil
+}
+
+func (s *FileRoomStore) LoadRooms() ([]core.RoomRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedLocked(), nil
+}
+
+func (s *FileRoomStore) SaveRoom(rec core.RoomRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rooms[rec.ID] = rec
+	return writeJSON(s.path, s.sortedLocked())
+}
+
+func (s *FileRoomStore) DeleteRoom(id domain.RoomID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.rooms[id]; !ok {
+		return nil
+	}
+	delete(s.rooms, id)
+	return writeJSON(s.path, s.sortedLocked())
+}
+
+func (s *FileRoomStore) sortedLocked() []core.RoomRecord {
+	out := make([]core.RoomRecord, 0, len(s.rooms))
+	for _, rec := range s.rooms {
+		out = append(out, rec)
+	}
+	slices.SortFunc(out, func(a, b core.RoomRecord) int { return cmp.Compare(a.ID, b.ID) })
+	return out
+}
+
+// readJSON decodes path into v, leaving v untouched if the file does not exist.
+func readJSON(path string, v any) error {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// writeJSON replaces path atomically with the JSON encoding of v.
+func writeJSON(path string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+)
+
+func TestFileRoomStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "rooms.json")
+	s, err := NewFileRoomStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recs, _ := s.LoadRooms(); len(recs) != 0 {
+		t.Fatalf("new store holds %v", recs)
+	}
+
+	lobby := core.RoomRecord{
+		ID:   "lobby",
+		Name: "Lobby",
+		Settings: domain.RoomSettings{
+			FloorControl: true,
+			FloorMaxHold: 30 * time.Second,
+			MaxMembers:   8,
+			Persistent:   true,
+		},
+		PasswordHash: []byte("$2a$10$hash"),
+		Roles:        map[domain.UserID]domain.RoomRole{"u1": domain.RoleOwner, "u2": domain.RoleModerator},
+	}
+	team := core.RoomRecord{ID: "team", Name: "Team", Settings: domain.RoomSettings{InviteOnly: true, Persistent: true}}
+	for _, rec := range []core.RoomRecord{team, lobby} {
+		if err := s.SaveRoom(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reopened, err := NewFileRoomStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs, err := reopened.LoadRooms()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []core.RoomRecord{lobby, team}; !reflect.DeepEqual(recs, want) {
+		t.Fatalf("reopened rooms = %+v, want %+v", recs, want)
+	}
+
+	team.Name = "Renamed"
+	if err := reopened.SaveRoom(team); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.DeleteRoom("lobby"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.DeleteRoom("missing"); err != nil {
+		t.Fatalf("deleting an unknown room: %v", err)
+	}
+
+	again, err := NewFileRoomStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs, _ = again.LoadRooms()
+	if want := []core.RoomRecord{team}; !reflect.DeepEqual(recs, want) {
+		t.Fatalf("rooms after update and delete = %+v, want %+v", recs, want)
+	}
+}
+
+func TestFileRoomStoreLeavesNoTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFileRoomStore(filepath.Join(dir, "rooms.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []domain.RoomID{"a", "b", "c"} {
+		if err := s.SaveRoom(core.RoomRecord{ID: id, Name: "room"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "rooms.json" {
+		t.Fatalf("store directory holds %v, want only rooms.json", entries)
+	}
+}
+
+func TestFileRoomStoreRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rooms.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileRoomStore(path); err == nil {
+		t.Fatal("a corrupt room file was accepted")
+	}
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dkeye/Voice/internal/domain"
+)
+
+// FileUserStore keeps user profiles in one JSON file, keyed by identity.
+// Like FileRoomStore it rewrites the whole file on every change.
+type FileUserStore struct {
+	path string
+
+	mu    sync.Mutex
+	users map[string]domain.UserProfile
+}
+
+// NewFileUserStore opens the store at path; a missing file is an empty store.
+func NewFileUserStore(path string) (*FileUserStore, error) {
+	s := &FileUserStore{path: path, users: make(map[string]domain.UserProfile)}
+	if err := readJSON(path, &s.users); err != nil {
+		return nil, fmt.Errorf("user store: %w", err)
+	}
+	return s, nil
+}
+
+func (s *FileUserStore) LoadUser(key string) (domain.UserProfile, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.users[key]
+	return p, ok, nil
+}
+
+func (s *FileUserStore) SaveUser(key string, p domain.UserProfile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[key] = p
+	return writeJSON(s.path, s.users)
+}
+
+func (s *FileUserStore) ExpireGuests(before time.Time, keep func(key string) bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for key, p := range s.users {
+		if expiredGuest(p, before) && !keep(key) {
+			delete(s.users, key)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	return n, writeJSON(s.path, s.users)
+}
+
+// expiredGuest reports whether p is a guest last seen before the given
+// time. Profiles saved before SeenAt existed go by their creation time.
+func expiredGuest(p domain.UserProfile, before time.Time) bool {
+	seen := p.SeenAt
+	if seen.IsZero() {
+		seen = p.CreatedAt
+	}
+	return p.IsGuest() && seen.Before(before)
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dkeye/Voice/internal/domain"
+)
+
+var storeNow = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestFileUserStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	s, err := NewFileUserStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := s.LoadUser("nobody"); ok || err != nil {
+		t.Fatalf("LoadUser on an empty store = %v, %v", ok, err)
+	}
+
+	account := domain.UserProfile{
+		ID:           "u1",
+		Username:     "alice",
+		Prefs:        domain.UserPrefs{StartMuted: true},
+		CreatedAt:    storeNow.Add(-time.Hour),
+		SeenAt:       storeNow,
+		Roles:        []string{"admin"},
+		Login:        "alice",
+		PasswordHash: []byte("$2a$10$hash"),
+	}
+	guest := domain.UserProfile{ID: "u2", Username: "guest", Prefs: domain.UserPrefs{StartDeafened: true}, CreatedAt: storeNow}
+	if err := s.SaveUser("account:alice", account); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveUser("cookie", guest); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileUserStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]domain.UserProfile{"account:alice": account, "cookie": guest} {
+		got, ok, err := reopened.LoadUser(key)
+		if err != nil || !ok {
+			t.Fatalf("LoadUser(%q) = %v, %v", key, ok, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("LoadUser(%q) = %+v, want %+v", key, got, want)
+		}
+	}
+}
+
+func TestFileUserStoreExpireGuests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	s, err := NewFileUserStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cutoff := storeNow.Add(-24 * time.Hour)
+	old := storeNow.Add(-48 * time.Hour)
+	profiles := map[string]domain.UserProfile{
+		"stale":  {ID: "u1", CreatedAt: old, SeenAt: old},
+		"legacy": {ID: "u2", CreatedAt: old},
+		"fresh":  {ID: "u3", CreatedAt: old, SeenAt: storeNow},
+		"online": {ID: "u4", CreatedAt: old, SeenAt: old},
+		"member": {ID: "u5", CreatedAt: old, SeenAt: old, Login: "bob"},
+	}
+	for key, p := range profiles {
+		if err := s.SaveUser(key, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keep := func(key string) bool { return key == "online" }
+	n, err := s.ExpireGuests(cutoff, keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("ExpireGuests removed %d profiles, want 2", n)
+	}
+
+	reopened, err := NewFileUserStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]bool{"stale": false, "legacy": false, "fresh": true, "online": true, "member": true} {
+		if _, ok, _ := reopened.LoadUser(key); ok != want {
+			t.Errorf("profile %q kept = %v, want %v", key, ok, want)
+		}
+	}
+
+	if n, err := reopened.ExpireGuests(cutoff, keep); n != 0 || err != nil {
+		t.Fatalf("second ExpireGuests = %d, %v; want nothing left to expire", n, err)
+	}
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dkeye/Voice/internal/domain"
+)
+
+// MemoryUserStore keeps account profiles until the process exits. Guest
+// profiles are not kept: they would last no longer than the registry's own
+// cache, which already reaps them, and would otherwise pile up here.
+type MemoryUserStore struct {
+	mu    sync.Mutex
+	users map[string]domain.UserProfile
+}
+
+func NewMemoryUserStore() *MemoryUserStore {
+	return &MemoryUserStore{users: make(map[string]domain.UserProfile)}
+}
+
+func (s *MemoryUserStore) LoadUser(key string) (domain.UserProfile, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.users[key]
+	return p, ok, nil
+}
+
+func (s *MemoryUserStore) SaveUser(key string, p domain.UserProfile) error {
+	if p.IsGuest() {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[key] = p
+	return nil
+}
+
+func (s *MemoryUserStore) ExpireGuests(before time.Time, keep func(key string) bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for key, p := range s.users {
+		if expiredGuest(p, before) && !keep(key) {
+			delete(s.users, key)
+			n++
+		}
+	}
+	return n, nil
+}
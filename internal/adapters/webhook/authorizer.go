@@ -0,0 +1,138 @@
+// Package webhook asks an external HTTP service to authorize joins and
+// room creation.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/rs/zerolog/log"
+)
+
+// Config describes the hook.
+type Config struct {
+	// URL receives a POST of core.AccessRequest as JSON and answers with
+	// core.AccessDecision.
+	URL string
+	// Timeout bounds one call, connection included.
+	Timeout time.Duration
+	// FailOpen allows the action when the hook cannot be reached or
+	// answers with anything but 2xx and a decision; otherwise it is denied.
+	FailOpen bool
+	// CacheTTL keeps decisions for the same user, action and room this
+	// long; zero asks every time.
+	CacheTTL time.Duration
+}
+
+type cached struct {
+	decision core.AccessDecision
+	expires  time.Time
+}
+
+// Authorizer implements core.Authorizer over HTTP.
+type Authorizer struct {
+	cfg    Config
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cached
+}
+
+func NewAuthorizer(cfg Config) *Authorizer {
+	return &Authorizer{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		cache:  make(map[string]cached),
+	}
+}
+
+func (a *Authorizer) Authorize(ctx context.Context, req core.AccessRequest) (core.AccessDecision, error) {
+	key := cacheKey(req)
+	if d, ok := a.lookup(key); ok {
+		return d, nil
+	}
+	d, err := a.call(ctx, req)
+	if err != nil {
+		log.Warn().Err(err).Str("module", "adapters.webhook").Str("action", string(req.Action)).Bool("fail_open", a.cfg.FailOpen).Msg("authorization hook failed")
+		if a.cfg.FailOpen {
+			return core.AccessDecision{Allow: true}, nil
+		}
+		return core.AccessDecision{}, err
+	}
+	a.store(key, d)
+	return d, nil
+}
+
+func (a *Authorizer) call(ctx context.Context, req core.AccessRequest) (core.AccessDecision, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return core.AccessDecision{}, err
+	}
+	if a.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.cfg.Timeout)
+		defer cancel()
+	}
+	hr, err := http.NewRequestWithContext(ctx, http.MethodPost, a.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return core.AccessDecision{}, err
+	}
+	hr.Header.Set("Content-Type", "application/json")
+	res, err := a.client.Do(hr)
+	if err != nil {
+		return core.AccessDecision{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return core.AccessDecision{}, fmt.Errorf("authorization hook: status %d", res.StatusCode)
+	}
+	var d core.AccessDecision
+	if err := json.NewDecoder(io.LimitReader(res.Body, 64<<10)).Decode(&d); err != nil {
+		return core.AccessDecision{}, fmt.Errorf("authorization hook: %w", err)
+	}
+	return d, nil
+}
+
+func (a *Authorizer) lookup(key string) (core.AccessDecision, bool) {
+	if a.cfg.CacheTTL <= 0 {
+		return core.AccessDecision{}, false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	c, ok := a.cache[key]
+	if !ok || time.Now().After(c.expires) {
+		return core.AccessDecision{}, false
+	}
+	return c.decision, true
+}
+
+func (a *Authorizer) store(key string, d core.AccessDecision) {
+	if a.cfg.CacheTTL <= 0 {
+		return
+	}
+	now := time.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for k, c := range a.cache {
+		if now.After(c.expires) {
+			delete(a.cache, k)
+		}
+	}
+	a.cache[key] = cached{decision: d, expires: now.Add(a.cfg.CacheTTL)}
+}
+
+// cacheKey covers everything a decision may depend on.
+func cacheKey(req core.AccessRequest) string {
+	return strings.Join([]string{
+		string(req.Action), string(req.User.ID), req.User.Username, req.Login, strings.Join(req.Roles, ","),
+		string(req.Room), string(req.RoomName),
+	}, "\x00")
+}
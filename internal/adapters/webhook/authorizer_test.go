@@ -0,0 +1,185 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+)
+
+// hook serves decide's answer and counts the calls it got.
+func hook(t *testing.T, decide func(core.AccessRequest) core.AccessDecision) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("hook got %s with Content-Type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		var req core.AccessRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		_ = json.NewEncoder(w).Encode(decide(req))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func joinRequest(room domain.RoomID) core.AccessRequest {
+	return core.AccessRequest{
+		Action: core.AccessJoin,
+		User:   domain.User{ID: "u1", Username: "alice"},
+		Login:  "alice",
+		Roles:  []string{"member"},
+		Room:   room,
+	}
+}
+
+func TestAuthorizerAllowAndDeny(t *testing.T) {
+	srv, _ := hook(t, func(req core.AccessRequest) core.AccessDecision {
+		if req.Room == "open" && req.Login == "alice" {
+			return core.AccessDecision{Allow: true}
+		}
+		return core.AccessDecision{Reason: "closed"}
+	})
+	a := NewAuthorizer(Config{URL: srv.URL, Timeout: time.Second})
+
+	d, err := a.Authorize(context.Background(), joinRequest("open"))
+	if err != nil || !d.Allow {
+		t.Fatalf("open room = %+v, %v; want allowed", d, err)
+	}
+	d, err = a.Authorize(context.Background(), joinRequest("private"))
+	if err != nil || d.Allow || d.Reason != "closed" {
+		t.Fatalf("private room = %+v, %v; want denied with a reason", d, err)
+	}
+}
+
+func TestAuthorizerCachesDecisions(t *testing.T) {
+	srv, calls := hook(t, func(core.AccessRequest) core.AccessDecision {
+		return core.AccessDecision{Allow: true}
+	})
+	a := NewAuthorizer(Config{URL: srv.URL, Timeout: time.Second, CacheTTL: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		if _, err := a.Authorize(context.Background(), joinRequest("room")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("hook called %d times for one request, want 1", n)
+	}
+	if _, err := a.Authorize(context.Background(), joinRequest("other")); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("hook called %d times for two rooms, want 2", n)
+	}
+}
+
+func TestAuthorizerCacheKeepsUsernamesApart(t *testing.T) {
+	srv, calls := hook(t, func(req core.AccessRequest) core.AccessDecision {
+		return core.AccessDecision{Allow: req.User.Username == "alice"}
+	})
+	a := NewAuthorizer(Config{URL: srv.URL, Timeout: time.Second, CacheTTL: time.Minute})
+
+	if d, _ := a.Authorize(context.Background(), joinRequest("room")); !d.Allow {
+		t.Fatal("alice was denied")
+	}
+	renamed := joinRequest("room")
+	renamed.User.Username = "mallory"
+	if d, _ := a.Authorize(context.Background(), renamed); d.Allow {
+		t.Fatal("a decision cached for alice went to another name")
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("hook called %d times, want 2", n)
+	}
+}
+
+func TestAuthorizerWithoutCacheAsksEveryTime(t *testing.T) {
+	srv, calls := hook(t, func(core.AccessRequest) core.AccessDecision {
+		return core.AccessDecision{Allow: true}
+	})
+	a := NewAuthorizer(Config{URL: srv.URL, Timeout: time.Second})
+
+	for i := 0; i < 3; i++ {
+		if _, err := a.Authorize(context.Background(), joinRequest("room")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("hook called %d times, want 3", n)
+	}
+}
+
+func TestAuthorizerFailures(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() { close(release) })
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	t.Cleanup(broken.Close)
+	garbled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("not json"))
+	}))
+	t.Cleanup(garbled.Close)
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"timeout", slow.URL},
+		{"status 500", broken.URL},
+		{"bad body", garbled.URL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			closed := NewAuthorizer(Config{URL: tt.url, Timeout: 50 * time.Millisecond})
+			d, err := closed.Authorize(context.Background(), joinRequest("room"))
+			if err == nil || d.Allow {
+				t.Fatalf("fail closed = %+v, %v; want an error and no allow", d, err)
+			}
+
+			open := NewAuthorizer(Config{URL: tt.url, Timeout: 50 * time.Millisecond, FailOpen: true})
+			d, err = open.Authorize(context.Background(), joinRequest("room"))
+			if err != nil || !d.Allow {
+				t.Fatalf("fail open = %+v, %v; want allowed", d, err)
+			}
+		})
+	}
+}
+
+func TestAuthorizerDoesNotCacheFailures(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(core.AccessDecision{Reason: "denied"})
+	}))
+	t.Cleanup(srv.Close)
+	a := NewAuthorizer(Config{URL: srv.URL, Timeout: time.Second, FailOpen: true, CacheTTL: time.Minute})
+
+	if d, _ := a.Authorize(context.Background(), joinRequest("room")); !d.Allow {
+		t.Fatal("unreachable hook with fail_open denied")
+	}
+	fail.Store(false)
+	if d, _ := a.Authorize(context.Background(), joinRequest("room")); d.Allow {
+		t.Fatal("fail-open allow was cached over the hook's own answer")
+	}
+}
@@ -0,0 +1,231 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxRoomPasswordLen is bcrypt's input limit.
+const MaxRoomPasswordLen = 72
+
+var (
+	ErrPasswordTooLong = errors.New("room password too long")
+	ErrBadPassword     = errors.New("wrong room password")
+	ErrBadInvite       = errors.New("invalid invite")
+	ErrInviteExpired   = errors.New("invite expired")
+	ErrInviteUsedUp    = errors.New("invite used up")
+)
+
+// HashRoomPassword returns the bcrypt hash stored in domain.RoomSettings.
+func HashRoomPassword(password string) ([]byte, error) {
+	if len(password) > MaxRoomPasswordLen {
+		return nil, ErrPasswordTooLong
+	}
+	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
+
+// CheckRoomPassword compares password with a hash from HashRoomPassword.
+func CheckRoomPassword(hash []byte, password string) error {
+	if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil {
+		return ErrBadPassword
+	}
+	return nil
+}
+
+// Invite is the decoded content of an invite token.
+type Invite struct {
+	ID      string
+	Room    domain.RoomID
+	Expires time.Time
+	// MaxUses is how many joins the invite admits; zero means unlimited.
+	MaxUses int
+}
+
+type inviteUse struct {
+	expires time.Time
+	n       int
+}
+
+// InviteBook issues HMAC-signed invite tokens and counts their uses.
+// Tokens carry everything needed to verify them; only use counts are kept,
+// in the store if there is one.
+type InviteBook struct {
+	secret []byte
+	store  core.InviteStore
+
+	mu   sync.Mutex
+	uses map[string]*inviteUse
+}
+
+// NewInviteBook signs tokens with secret. Without a secret a random key is
+// used, so tokens stop working after a restart.
+func NewInviteBook(secret []byte) *InviteBook {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+	return &InviteBook{secret: secret, uses: make(map[string]*inviteUse)}
+}
+
+// NewPersistentInviteBook is NewInviteBook with use counts kept in store and
+// restored from it.
+func NewPersistentInviteBook(secret []byte, store core.InviteStore) (*InviteBook, error) {
+	recs, err := store.LoadInviteUses()
+	if err != nil {
+		return nil, err
+	}
+	b := NewInviteBook(secret)
+	b.store = store
+	for _, rec := range recs {
+		b.uses[rec.ID] = &inviteUse{expires: rec.Expires, n: rec.Uses}
+	}
+	b.mu.Lock()
+	b.pruneLocked()
+	b.mu.Unlock()
+	log.Info().Str("module", "app.invites").Int("invites", len(b.uses)).Msg("restored invite uses")
+	return b, nil
+}
+
+// Issue creates a token for room valid for ttl and at most maxUses joins.
+func (b *InviteBook) Issue(room domain.RoomID, ttl time.Duration, maxUses int) (string, Invite) {
+	inv := Invite{
+		ID:      uuid.NewString(),
+		Room:    room,
+		Expires: time.Now().Add(ttl).Truncate(time.Second),
+		MaxUses: maxUses,
+	}
+	payload := fmt.Sprintf("%s|%s|%d|%d", inv.Room, inv.ID, inv.Expires.Unix(), inv.MaxUses)
+	token := enc(payload) + "." + enc(string(b.sign(payload)))
+
+	b.mu.Lock()
+	b.pruneLocked()
+	b.mu.Unlock()
+	log.Info().Str("module", "app.invites").Str("room_id", string(room)).Str("invite", inv.ID).Time("expires", inv.Expires).Int("max_uses", maxUses).Msg("invite issued")
+	return token, inv
+}
+
+// Check verifies token for room without consuming a use.
+func (b *InviteBook) Check(room domain.RoomID, token string) (Invite, error) {
+	inv, err := b.verify(room, token)
+	if err != nil {
+		return Invite{}, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if u, ok := b.uses[inv.ID]; ok && inv.MaxUses > 0 && u.n >= inv.MaxUses {
+		return Invite{}, ErrInviteUsedUp
+	}
+	return inv, nil
+}
+
+// Redeem verifies token for room and consumes one use.
+func (b *InviteBook) Redeem(room domain.RoomID, token string) error {
+	inv, err := b.verify(room, token)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	u, ok := b.uses[inv.ID]
+	if !ok {
+		u = &inviteUse{expires: inv.Expires}
+		b.uses[inv.ID] = u
+	}
+	if inv.MaxUses > 0 && u.n >= inv.MaxUses {
+		return ErrInviteUsedUp
+	}
+	u.n++
+	if b.store != nil {
+		if err := b.store.SaveInviteUses(core.InviteUses{ID: inv.ID, Expires: u.expires, Uses: u.n}); err != nil {
+			log.Error().Err(err).Str("module", "app.invites").Str("invite", inv.ID).Msg("failed to save invite uses")
+		}
+	}
+	log.Info().Str("module", "app.invites").Str("room_id", string(room)).Str("invite", inv.ID).Int("uses", u.n).Msg("invite redeemed")
+	return nil
+}
+
+// verify checks the signature, room and expiry of token.
+func (b *InviteBook) verify(room domain.RoomID, token string) (Invite, error) {
+	inv, err := b.parse(token)
+	if err != nil || inv.Room != room {
+		return Invite{}, ErrBadInvite
+	}
+	if time.Now().After(inv.Expires) {
+		return Invite{}, ErrInviteExpired
+	}
+	return inv, nil
+}
+
+func (b *InviteBook) parse(token string) (Invite, error) {
+	p, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return Invite{}, ErrBadInvite
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(p)
+	if err != nil {
+		return Invite{}, ErrBadInvite
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, b.sign(string(payload))) {
+		return Invite{}, ErrBadInvite
+	}
+	parts := strings.Split(string(payload), "|")
+	if len(parts) != 4 {
+		return Invite{}, ErrBadInvite
+	}
+	exp, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return Invite{}, ErrBadInvite
+	}
+	maxUses, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return Invite{}, ErrBadInvite
+	}
+	return Invite{
+		ID:      parts[1],
+		Room:    domain.RoomID(parts[0]),
+		Expires: time.Unix(exp, 0),
+		MaxUses: maxUses,
+	}, nil
+}
+
+func (b *InviteBook) sign(payload string) []byte {
+	h := hmac.New(sha256.New, b.secret)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
+
+// pruneLocked forgets use counts of invites that can no longer be redeemed.
+func (b *InviteBook) pruneLocked() {
+	now := time.Now()
+	for id, u := range b.uses {
+		if !now.After(u.expires) {
+			continue
+		}
+		delete(b.uses, id)
+		if b.store != nil {
+			if err := b.store.DeleteInviteUses(id); err != nil {
+				log.Error().Err(err).Str("module", "app.invites").Str("invite", id).Msg("failed to delete invite uses")
+			}
+		}
+	}
+}
+
+func enc(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
@@ -0,0 +1,154 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+)
+
+// memInviteStore is an in-memory core.InviteStore.
+type memInviteStore struct {
+	mu   sync.Mutex
+	uses map[string]core.InviteUses
+}
+
+func newMemInviteStore() *memInviteStore {
+	return &memInviteStore{uses: make(map[string]core.InviteUses)}
+}
+
+func (s *memInviteStore) LoadInviteUses() ([]core.InviteUses, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []core.InviteUses
+	for _, u := range s.uses {
+		out = append(out, u)
+	}
+	return out, nil
+}
+
+func (s *memInviteStore) SaveInviteUses(u core.InviteUses) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.uses[u.ID] = u
+	return nil
+}
+
+func (s *memInviteStore) DeleteInviteUses(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.uses, id)
+	return nil
+}
+
+func TestInviteBookRedeemCountsUses(t *testing.T) {
+	b := NewInviteBook([]byte("secret"))
+	token, inv := b.Issue("room", time.Hour, 2)
+	if inv.Room != "room" || inv.MaxUses != 2 {
+		t.Fatalf("issued invite = %+v", inv)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := b.Check("room", token); err != nil {
+			t.Fatalf("check before use %d: %v", i+1, err)
+		}
+		if err := b.Redeem("room", token); err != nil {
+			t.Fatalf("use %d: %v", i+1, err)
+		}
+	}
+	if _, err := b.Check("room", token); !errors.Is(err, ErrInviteUsedUp) {
+		t.Fatalf("check after last use = %v, want %v", err, ErrInviteUsedUp)
+	}
+	if err := b.Redeem("room", token); !errors.Is(err, ErrInviteUsedUp) {
+		t.Fatalf("use past the limit = %v, want %v", err, ErrInviteUsedUp)
+	}
+}
+
+func TestInviteBookCheckDoesNotSpend(t *testing.T) {
+	b := NewInviteBook([]byte("secret"))
+	token, _ := b.Issue("room", time.Hour, 1)
+	for i := 0; i < 3; i++ {
+		if _, err := b.Check("room", token); err != nil {
+			t.Fatalf("check %d: %v", i+1, err)
+		}
+	}
+	if err := b.Redeem("room", token); err != nil {
+		t.Fatalf("redeem after checks: %v", err)
+	}
+}
+
+func TestInviteBookRejects(t *testing.T) {
+	b := NewInviteBook([]byte("secret"))
+	token, _ := b.Issue("room", time.Hour, 0)
+	expired, _ := b.Issue("room", -time.Minute, 0)
+	other, _ := NewInviteBook([]byte("other")).Issue("room", time.Hour, 0)
+	payload, sig, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name  string
+		room  domain.RoomID
+		token string
+		want  error
+	}{
+		{"wrong room", "elsewhere", token, ErrBadInvite},
+		{"expired", "room", expired, ErrInviteExpired},
+		{"other secret", "room", other, ErrBadInvite},
+		{"no signature", "room", payload, ErrBadInvite},
+		{"tampered payload", "room", payload + "x." + sig, ErrBadInvite},
+		{"garbage", "room", "not a token", ErrBadInvite},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := b.Redeem(tt.room, tt.token); !errors.Is(err, tt.want) {
+				t.Fatalf("Redeem = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInviteBookUnlimited(t *testing.T) {
+	b := NewInviteBook([]byte("secret"))
+	token, _ := b.Issue("room", time.Hour, 0)
+	for i := 0; i < 10; i++ {
+		if err := b.Redeem("room", token); err != nil {
+			t.Fatalf("use %d of an unlimited invite: %v", i+1, err)
+		}
+	}
+}
+
+func TestPersistentInviteBookKeepsUsesAcrossRestart(t *testing.T) {
+	st := newMemInviteStore()
+	b, err := NewPersistentInviteBook([]byte("secret"), st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := b.Issue("room", time.Hour, 1)
+	if err := b.Redeem("room", token); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted, err := NewPersistentInviteBook([]byte("secret"), st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.Redeem("room", token); !errors.Is(err, ErrInviteUsedUp) {
+		t.Fatalf("use after restart = %v, want %v", err, ErrInviteUsedUp)
+	}
+}
+
+func TestPersistentInviteBookDropsExpiredCounts(t *testing.T) {
+	st := newMemInviteStore()
+	_ = st.SaveInviteUses(core.InviteUses{ID: "old", Expires: time.Now().Add(-time.Hour), Uses: 3})
+	_ = st.SaveInviteUses(core.InviteUses{ID: "live", Expires: time.Now().Add(time.Hour), Uses: 1})
+	if _, err := NewPersistentInviteBook([]byte("secret"), st); err != nil {
+		t.Fatal(err)
+	}
+	recs, _ := st.LoadInviteUses()
+	if len(recs) != 1 || recs[0].ID != "live" {
+		t.Fatalf("stored uses after load = %+v, want only the live invite", recs)
+	}
+}
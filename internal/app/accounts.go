@@ -0,0 +1,132 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	accountPrefix = "account:"
+
+	MinAccountPasswordLen = 8
+	// MaxAccountPasswordLen is bcrypt's input limit.
+	MaxAccountPasswordLen = 72
+)
+
+var (
+	ErrNoAccounts     = errors.New("accounts need a user store")
+	ErrBadLogin       = errors.New("login must be 1-36 letters, digits, '.', '-' or '_'")
+	ErrWeakPassword   = errors.New("password must be 8-72 bytes")
+	ErrAccountExists  = errors.New("login already taken")
+	ErrBadCredentials = errors.New("wrong login or password")
+)
+
+// AccountClient is the client identity of an account. It takes the place of
+// the guest cookie once the connection is logged in, so every device of the
+// account shares one user.
+func AccountClient(login string) core.ClientID {
+	return core.ClientID(accountPrefix + login)
+}
+
+// NormalizeLogin lowercases login and checks its alphabet.
+func NormalizeLogin(login string) (string, error) {
+	login = strings.ToLower(strings.TrimSpace(login))
+	if login == "" || len(login) > domain.MaxUsernameLen {
+		return "", ErrBadLogin
+	}
+	for _, c := range login {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '.' && c != '-' && c != '_' {
+			return "", ErrBadLogin
+		}
+	}
+	return login, nil
+}
+
+// Register creates an account named login; its display name starts as the
+// login. The bcrypt hash of password is kept in the user store.
+func (r *Registry) Register(login, password string) (domain.UserProfile, error) {
+	if r.Store == nil {
+		return domain.UserProfile{}, ErrNoAccounts
+	}
+	login, err := NormalizeLogin(login)
+	if err != nil {
+		return domain.UserProfile{}, err
+	}
+	if len(password) < MinAccountPasswordLen || len(password) > MaxAccountPasswordLen {
+		return domain.UserProfile{}, ErrWeakPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return domain.UserProfile{}, err
+	}
+
+	u, err := domain.NewUser(login)
+	if err != nil {
+		return domain.UserProfile{}, err
+	}
+	cid := AccountClient(login)
+	e := &userEntry{User: u, Created: time.Now().UTC(), Login: login, hash: hash, seen: time.Now(), stored: true}
+
+	// The entry claims the login in the cache before the write, which
+	// happens outside mu; a failed write gives the login back.
+	r.mu.Lock()
+	_, taken := r.users[cid]
+	if !taken {
+		_, taken = r.loadUserLocked(cid)
+	}
+	if taken {
+		r.mu.Unlock()
+		return domain.UserProfile{}, ErrAccountExists
+	}
+	r.users[cid] = e
+	p := e.profile()
+	r.mu.Unlock()
+
+	r.saveMu.Lock()
+	err = r.Store.SaveUser(string(cid), p)
+	r.saveMu.Unlock()
+	if err != nil {
+		r.mu.Lock()
+		if r.users[cid] == e {
+			delete(r.users, cid)
+		}
+		r.mu.Unlock()
+		return domain.UserProfile{}, err
+	}
+	log.Info().Str("module", "app.registry").Str("login", login).Str("user_id", string(u.ID)).Msg("account registered")
+	return p, nil
+}
+
+// Login checks password against the account's stored hash.
+func (r *Registry) Login(login, password string) (domain.UserProfile, error) {
+	login, err := NormalizeLogin(login)
+	if err != nil {
+		return domain.UserProfile{}, ErrBadCredentials
+	}
+	r.mu.Lock()
+	cid := AccountClient(login)
+	e, ok := r.users[cid]
+	if !ok {
+		if e, ok = r.loadUserLocked(cid); ok {
+			r.users[cid] = e
+		}
+	}
+	var p domain.UserProfile
+	if ok {
+		e.seen = time.Now()
+		p = e.profile()
+	}
+	r.mu.Unlock()
+
+	if len(p.PasswordHash) == 0 || bcrypt.CompareHashAndPassword(p.PasswordHash, []byte(password)) != nil {
+		return domain.UserProfile{}, ErrBadCredentials
+	}
+	log.Info().Str("module", "app.registry").Str("login", login).Msg("account login")
+	return p, nil
+}
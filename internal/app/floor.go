@@ -0,0 +1,224 @@
+package app
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// FloorState is a snapshot of a room's push-to-talk floor.
+type FloorState struct {
+	Holder core.SessionID
+	Queue  []core.SessionID
+}
+
+// Floor is the push-to-talk state of one room: a single holder, a FIFO queue
+// of waiting speakers and a maximum hold time after which the floor passes on.
+type Floor struct {
+	mu       sync.Mutex
+	maxHold  time.Duration
+	holder   core.SessionID
+	queue    []core.SessionID
+	timer    *time.Timer
+	deadline time.Time
+	onChange func(FloorState, core.FloorReason)
+}
+
+func newFloor(maxHold time.Duration, onChange func(FloorState, core.FloorReason)) *Floor {
+	return &Floor{maxHold: maxHold, onChange: onChange}
+}
+
+// Request gives sid the floor if it is free, otherwise queues sid.
+// It reports whether sid holds the floor afterwards.
+func (f *Floor) Request(sid core.SessionID) bool {
+	f.mu.Lock()
+	switch {
+	case f.holder == sid:
+		f.mu.Unlock()
+		return true
+	case f.holder == "":
+		f.grantLocked(sid)
+	case !slices.Contains(f.queue, sid):
+		f.queue = append(f.queue, sid)
+	}
+	granted := f.holder == sid
+	f.mu.Unlock()
+	f.notify(core.FloorRequested)
+	return granted
+}
+
+// Release drops sid's floor or its place in the queue.
+func (f *Floor) Release(sid core.SessionID) {
+	f.remove(sid, core.FloorReleased)
+}
+
+// Leave is Release for a member who left the room.
+func (f *Floor) Leave(sid core.SessionID) {
+	f.remove(sid, core.FloorLeft)
+}
+
+// Replace gives sid the floor or queue place of old, as when a member moves
+// to another device. The hold time keeps running.
+func (f *Floor) Replace(old, sid core.SessionID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if i := slices.Index(f.queue, old); i >= 0 {
+		f.queue[i] = sid
+	}
+	if f.holder != old {
+		return
+	}
+	f.holder = sid
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = time.AfterFunc(time.Until(f.deadline), func() { f.expire(sid) })
+	}
+}
+
+// Grant hands the floor to sid immediately, ahead of the queue.
+func (f *Floor) Grant(sid core.SessionID) {
+	f.mu.Lock()
+	f.removeQueuedLocked(sid)
+	f.grantLocked(sid)
+	f.mu.Unlock()
+	f.notify(core.FloorOverride)
+}
+
+// Revoke takes the floor from its holder and passes it to the next in queue.
+func (f *Floor) Revoke() {
+	f.mu.Lock()
+	f.nextLocked()
+	f.mu.Unlock()
+	f.notify(core.FloorOverride)
+}
+
+func (f *Floor) State() FloorState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stateLocked()
+}
+
+// Stop cancels the hold timer; the floor must not be used afterwards.
+func (f *Floor) Stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+	f.onChange = nil
+}
+
+func (f *Floor) remove(sid core.SessionID, reason core.FloorReason) {
+	f.mu.Lock()
+	changed := f.removeQueuedLocked(sid)
+	if f.holder == sid {
+		f.nextLocked()
+		changed = true
+	}
+	f.mu.Unlock()
+	if changed {
+		f.notify(reason)
+	}
+}
+
+func (f *Floor) expire(holder core.SessionID) {
+	f.mu.Lock()
+	if f.holder != holder {
+		f.mu.Unlock()
+		return
+	}
+	log.Info().Str("module", "app.floor").Str("sid", string(holder)).Msg("floor hold time expired")
+	f.nextLocked()
+	f.mu.Unlock()
+	f.notify(core.FloorExpired)
+}
+
+func (f *Floor) nextLocked() {
+	if len(f.queue) == 0 {
+		f.grantLocked("")
+		return
+	}
+	next := f.queue[0]
+	f.queue = f.queue[1:]
+	f.grantLocked(next)
+}
+
+func (f *Floor) grantLocked(sid core.SessionID) {
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+	f.holder = sid
+	if sid != "" && f.maxHold > 0 {
+		f.deadline = time.Now().Add(f.maxHold)
+		f.timer = time.AfterFunc(f.maxHold, func() { f.expire(sid) })
+	}
+}
+
+func (f *Floor) removeQueuedLocked(sid core.SessionID) bool {
+	i := slices.Index(f.queue, sid)
+	if i < 0 {
+		return false
+	}
+	f.queue = slices.Delete(f.queue, i, i+1)
+	return true
+}
+
+func (f *Floor) stateLocked() FloorState {
+	return FloorState{Holder: f.holder, Queue: slices.Clone(f.queue)}
+}
+
+func (f *Floor) notify(reason core.FloorReason) {
+	f.mu.Lock()
+	state, onChange := f.stateLocked(), f.onChange
+	f.mu.Unlock()
+	if onChange != nil {
+		onChange(state, reason)
+	}
+}
+
+// FloorManager keeps one Floor per floor-controlled room.
+type FloorManager struct {
+	mu     sync.Mutex
+	floors map[domain.RoomID]*Floor
+}
+
+func NewFloorManager() *FloorManager {
+	return &FloorManager{floors: make(map[domain.RoomID]*Floor)}
+}
+
+// GetOrCreate returns the floor of the room, creating it on first use.
+func (m *FloorManager) GetOrCreate(id domain.RoomID, maxHold time.Duration, onChange func(FloorState, core.FloorReason)) *Floor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if f, ok := m.floors[id]; ok {
+		return f
+	}
+	f := newFloor(maxHold, onChange)
+	m.floors[id] = f
+	log.Info().Str("module", "app.floor").Str("room_id", string(id)).Dur("max_hold", maxHold).Msg("floor created")
+	return f
+}
+
+func (m *FloorManager) Get(id domain.RoomID) (*Floor, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, ok := m.floors[id]
+	return f, ok
+}
+
+// Drop stops and forgets the floor of a closed room.
+func (m *FloorManager) Drop(id domain.RoomID) {
+	m.mu.Lock()
+	f, ok := m.floors[id]
+	delete(m.floors, id)
+	m.mu.Unlock()
+	if ok {
+		f.Stop()
+	}
+}
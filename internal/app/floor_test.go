@@ -0,0 +1,187 @@
+package app
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+)
+
+// floorEvents records what a Floor reports through onChange.
+type floorEvents struct {
+	mu      sync.Mutex
+	reasons []core.FloorReason
+	states  []FloorState
+}
+
+func (e *floorEvents) record(st FloorState, reason core.FloorReason) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.reasons = append(e.reasons, reason)
+	e.states = append(e.states, st)
+}
+
+func (e *floorEvents) last() (FloorState, core.FloorReason) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.reasons) == 0 {
+		return FloorState{}, ""
+	}
+	return e.states[len(e.states)-1], e.reasons[len(e.reasons)-1]
+}
+
+func assertFloor(t *testing.T, f *Floor, holder core.SessionID, queue ...core.SessionID) {
+	t.Helper()
+	st := f.State()
+	if st.Holder != holder {
+		t.Fatalf("holder = %q, want %q", st.Holder, holder)
+	}
+	if !slices.Equal(st.Queue, queue) {
+		t.Fatalf("queue = %v, want %v", st.Queue, queue)
+	}
+}
+
+func TestFloorRequestQueuesInOrder(t *testing.T) {
+	var ev floorEvents
+	f := newFloor(0, ev.record)
+	defer f.Stop()
+
+	if !f.Request("a") {
+		t.Fatal("first request did not get the floor")
+	}
+	if f.Request("b") || f.Request("c") {
+		t.Fatal("request got a taken floor")
+	}
+	if !f.Request("a") {
+		t.Fatal("holder asking again lost the floor")
+	}
+	f.Request("b")
+	assertFloor(t, f, "a", "b", "c")
+
+	f.Release("a")
+	assertFloor(t, f, "b", "c")
+	if _, reason := ev.last(); reason != core.FloorReleased {
+		t.Fatalf("reason = %q, want %q", reason, core.FloorReleased)
+	}
+}
+
+func TestFloorLeaveDropsQueuedMember(t *testing.T) {
+	var ev floorEvents
+	f := newFloor(0, ev.record)
+	defer f.Stop()
+
+	f.Request("a")
+	f.Request("b")
+	f.Request("c")
+	f.Leave("b")
+	assertFloor(t, f, "a", "c")
+	if _, reason := ev.last(); reason != core.FloorLeft {
+		t.Fatalf("reason = %q, want %q", reason, core.FloorLeft)
+	}
+
+	n := len(ev.reasons)
+	f.Release("stranger")
+	if len(ev.reasons) != n {
+		t.Fatal("releasing a floor one never asked for was announced")
+	}
+}
+
+func TestFloorGrantAndRevoke(t *testing.T) {
+	var ev floorEvents
+	f := newFloor(0, ev.record)
+	defer f.Stop()
+
+	f.Request("a")
+	f.Request("b")
+	f.Request("c")
+
+	f.Grant("c")
+	assertFloor(t, f, "c", "b")
+	if _, reason := ev.last(); reason != core.FloorOverride {
+		t.Fatalf("reason = %q, want %q", reason, core.FloorOverride)
+	}
+
+	f.Revoke()
+	assertFloor(t, f, "b")
+	f.Revoke()
+	assertFloor(t, f, "")
+}
+
+func TestFloorReplaceKeepsPlace(t *testing.T) {
+	var ev floorEvents
+	f := newFloor(0, ev.record)
+	defer f.Stop()
+
+	f.Request("a")
+	f.Request("b")
+	f.Request("c")
+	f.Replace("b", "b2")
+	assertFloor(t, f, "a", "b2", "c")
+	f.Replace("a", "a2")
+	assertFloor(t, f, "a2", "b2", "c")
+
+	f.Release("a2")
+	assertFloor(t, f, "b2", "c")
+}
+
+func TestFloorReplacedHolderExpires(t *testing.T) {
+	var ev floorEvents
+	f := newFloor(20*time.Millisecond, ev.record)
+	defer f.Stop()
+
+	f.Request("a")
+	f.Request("b")
+	f.Replace("a", "a2")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if st, reason := ev.last(); reason == core.FloorExpired {
+			if st.Holder != "b" {
+				t.Fatalf("holder after expiry = %q, want %q", st.Holder, "b")
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("hold time of the replaced holder never expired")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFloorHoldExpires(t *testing.T) {
+	var ev floorEvents
+	f := newFloor(20*time.Millisecond, ev.record)
+	defer f.Stop()
+
+	f.Request("a")
+	f.Request("b")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if st, reason := ev.last(); reason == core.FloorExpired {
+			if st.Holder != "b" {
+				t.Fatalf("holder after expiry = %q, want %q", st.Holder, "b")
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("hold time never expired")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFloorManagerDrop(t *testing.T) {
+	m := NewFloorManager()
+	f := m.GetOrCreate("room", time.Minute, nil)
+	if again := m.GetOrCreate("room", time.Minute, nil); again != f {
+		t.Fatal("GetOrCreate made a second floor for the same room")
+	}
+	f.Request("a")
+	m.Drop("room")
+	if _, ok := m.Get("room"); ok {
+		t.Fatal("dropped floor is still there")
+	}
+}
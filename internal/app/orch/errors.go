@@ -0,0 +1,68 @@
+package orch
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dkeye/Voice/internal/domain"
+)
+
+var (
+	ErrNotInRoom      = errors.New("not in room")
+	ErrAlreadyInRoom  = errors.New("already in room")
+	ErrRoomNotFound   = errors.New("room not found")
+	ErrRoomFull       = errors.New("room is full")
+	ErrServerBusy     = errors.New("server is at capacity")
+	ErrNoSession      = errors.New("no session")
+	ErrNoSuchMember   = errors.New("no such member")
+	ErrNotModerator   = errors.New("not a moderator")
+	ErrNotStage       = errors.New("room is not in stage mode")
+	ErrAlreadySpeaker = errors.New("already a speaker")
+	ErrAudience       = errors.New("audience members cannot speak")
+	ErrNoFloorControl = errors.New("room has no floor control")
+	ErrNoTargets      = errors.New("no whisper targets")
+	ErrNotWhispering  = errors.New("not whispering")
+	ErrNoSuchMessage  = errors.New("no such chat message")
+	ErrInviteRequired = errors.New("room requires an invite")
+	ErrNotOwner       = errors.New("not the room owner")
+	ErrOutranked      = errors.New("target has an equal or higher room role")
+	ErrBadRole        = errors.New("unknown room role")
+	ErrBanned         = errors.New("banned from room")
+	ErrNotBanned      = errors.New("user is not banned")
+	ErrAccessDenied   = errors.New("access denied")
+	ErrNotAdmin       = errors.New("admin role required")
+)
+
+// DeniedError is an Authorizer's refusal. It matches ErrAccessDenied and
+// carries the reason to show the user.
+type DeniedError struct {
+	Reason string
+}
+
+func (e *DeniedError) Error() string {
+	if e.Reason == "" {
+		return ErrAccessDenied.Error()
+	}
+	return ErrAccessDenied.Error() + ": " + e.Reason
+}
+
+func (e *DeniedError) Unwrap() error { return ErrAccessDenied }
+
+// BanError rejects a join by a banned user. It matches ErrBanned and
+// carries the ban so the caller can tell the user how long it lasts.
+type BanError struct {
+	Ban domain.Ban
+}
+
+func (e *BanError) Error() string {
+	msg := ErrBanned.Error()
+	if e.Ban.Until != nil {
+		msg += " until " + e.Ban.Until.UTC().Format(time.RFC3339)
+	}
+	if e.Ban.Reason != "" {
+		msg += ": " + e.Ban.Reason
+	}
+	return msg
+}
+
+func (e *BanError) Unwrap() error { return ErrBanned }
@@ -0,0 +1,93 @@
+package orch
+
+import (
+	"context"
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// RunJanitor closes expired rooms and reaps dead sessions and idle users
+// every interval until ctx is done.
+func (o *Orchestrator) RunJanitor(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	log.Info().Str("module", "orch.janitor").Dur("interval", interval).Msg("janitor started")
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			o.sweepRooms(now)
+			o.sweepSessions(now)
+		}
+	}
+}
+
+// sweepRooms closes rooms that were never joined within RoomIdleTTL and
+// rooms that stayed empty past RoomEmptyGrace.
+func (o *Orchestrator) sweepRooms(now time.Time) {
+	for _, info := range o.Rooms.List() {
+		room, ok := o.Rooms.GetRoom(info.ID)
+		if !ok {
+			continue
+		}
+		if reason, expired := o.roomExpired(room, now); expired {
+			o.EvictRoom(info.ID, reason)
+		}
+	}
+}
+
+// sweepSessions unbinds sessions whose signal is gone for good, ending
+// their media and membership, then forgets users idle past UserTTL and
+// stored guests idle past GuestTTL.
+func (o *Orchestrator) sweepSessions(now time.Time) {
+	for _, sid := range o.Registry.StaleSessions() {
+		log.Info().Str("module", "orch.janitor").Str("sid", string(sid)).Msg("reaping stale session")
+		roomID, _, _ := o.Registry.RoomOf(sid)
+		var self core.MemberDTO
+		room, inRoom := o.Rooms.GetRoom(roomID)
+		if inRoom {
+			self, inRoom = room.MemberDTO(sid)
+		}
+		o.KickBySID(sid)
+		o.Registry.Unbind(sid)
+		if inRoom {
+			o.publish(core.MemberLeft{Room: roomID, User: self.User()})
+		}
+	}
+	if o.UserTTL > 0 {
+		o.Registry.ReapUsers(now, o.UserTTL)
+	}
+	if o.GuestTTL > 0 {
+		o.Registry.ExpireGuests(now, o.GuestTTL)
+	}
+	st := o.Stats()
+	log.Debug().
+		Str("module", "orch.janitor").
+		Int("sessions", st.Sessions).
+		Int("detached", st.Detached).
+		Int("users", st.Users).
+		Int("rooms", st.Rooms).
+		Msg("sweep done")
+}
+
+func (o *Orchestrator) roomExpired(room core.RoomService, now time.Time) (core.CloseReason, bool) {
+	if room.Room().Settings.Persistent {
+		return "", false
+	}
+	phase, since := room.Phase()
+	switch phase {
+	case domain.RoomCreated:
+		return core.RoomClosedIdle, o.RoomIdleTTL > 0 && now.Sub(since) >= o.RoomIdleTTL
+	case domain.RoomEmpty:
+		return core.RoomClosedEmpty, now.Sub(since) >= o.RoomEmptyGrace
+	default:
+		return "", false
+	}
+}
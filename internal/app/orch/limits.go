@@ -0,0 +1,61 @@
+package orch
+
+import (
+	"context"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/rs/zerolog/log"
+)
+
+// Limits bounds what a single server takes on so one busy room cannot
+// starve the rest. Zero means unlimited.
+type Limits struct {
+	// RoomMembers is the default and the ceiling for a room's MaxMembers.
+	RoomMembers int
+	// MaxSessions caps signal sessions, detached ones included.
+	MaxSessions int
+	// MaxPeers caps live WebRTC PeerConnections.
+	MaxPeers int
+	// MaxRelays caps upstream audio relays.
+	MaxRelays int
+}
+
+// roomSize picks a room's capacity from the one asked for at creation.
+func (l Limits) roomSize(requested int) int {
+	if requested <= 0 || (l.RoomMembers > 0 && requested > l.RoomMembers) {
+		return l.RoomMembers
+	}
+	return requested
+}
+
+// BindSession registers a new session for cid if the server may take one
+// more, and returns its resume token.
+func (o *Orchestrator) BindSession(cid core.ClientID, sid core.SessionID, sess core.MemberSession, cancel context.CancelFunc) (string, error) {
+	token, ok := o.Registry.BindSignal(cid, sid, sess, cancel, o.Limits.MaxSessions)
+	if !ok {
+		log.Warn().Str("module", "orch").Int("max", o.Limits.MaxSessions).Msg("session limit reached")
+		return "", ErrServerBusy
+	}
+	return token, nil
+}
+
+// AdmitMedia reports whether sid may open a PeerConnection. A session
+// renegotiating keeps the slots it already holds.
+func (o *Orchestrator) AdmitMedia(sid core.SessionID) error {
+	sess, ok := o.Registry.GetSession(sid)
+	if !ok {
+		return ErrNoSession
+	}
+	if sess.Media() != nil {
+		return nil
+	}
+	if max := o.Limits.MaxPeers; max > 0 && o.Registry.MediaCount() >= max {
+		log.Warn().Str("module", "orch").Str("sid", string(sid)).Int("max", max).Msg("peer limit reached")
+		return ErrServerBusy
+	}
+	if max := o.Limits.MaxRelays; max > 0 && o.Relays != nil && o.Relays.Count() >= max {
+		log.Warn().Str("module", "orch").Str("sid", string(sid)).Int("max", max).Msg("relay limit reached")
+		return ErrServerBusy
+	}
+	return nil
+}
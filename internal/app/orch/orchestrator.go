@@ -1,6 +1,8 @@
 package orch
 
 import (
+	"time"
+
 	"github.com/dkeye/Voice/internal/app"
 	"github.com/dkeye/Voice/internal/app/sfu"
 	"github.com/dkeye/Voice/internal/core"
@@ -11,6 +13,35 @@ type Orchestrator struct {
 	Rooms    core.RoomManager
 	Policy   app.Policy
 	Relays   *sfu.RelayManager
+	Floors   *app.FloorManager
+	Invites  *app.InviteBook
+	// Events receives server-initiated notifications; nil drops them.
+	Events core.EventSink
+	// Authorizer, if set, is asked before every join and room creation.
+	Authorizer core.Authorizer
+
+	// EchoDelay is how long the echo test holds audio before playing it back.
+	EchoDelay time.Duration
+	// FloorMaxHold is the default floor hold limit for push-to-talk rooms.
+	FloorMaxHold time.Duration
+	// ChatHistory is how many chat messages a room keeps for late joiners.
+	ChatHistory int
+	// KickCooldown bans a kicked member for this long; zero lets them rejoin at once.
+	KickCooldown time.Duration
+	// Limits caps rooms and server-wide resources.
+	Limits Limits
+	// RoomIdleTTL closes a room nobody joined within this long after creation.
+	RoomIdleTTL time.Duration
+	// RoomEmptyGrace keeps an empty room open this long for members to come
+	// back; zero closes it as soon as the last member leaves.
+	RoomEmptyGrace time.Duration
+	// UserTTL forgets a user this long after its last session went away.
+	UserTTL time.Duration
+	// GuestTTL deletes a stored guest profile this long after it was last seen.
+	GuestTTL time.Duration
+	// AdminRole is the identity role allowed to create persistent rooms;
+	// empty means nobody may.
+	AdminRole string
 }
 
 func NewOrchestrator(
@@ -24,10 +55,18 @@ func NewOrchestrator(
 		Rooms:    roomsManager,
 		Policy:   policy,
 		Relays:   relayManager,
+		Floors:   app.NewFloorManager(),
+		Invites:  app.NewInviteBook(nil),
 	}
 	return o
 }
 
+func (o *Orchestrator) publish(ev core.Event) {
+	if o.Events != nil {
+		o.Events.Publish(ev)
+	}
+}
+
 func (o *Orchestrator) OnFrame(sid core.SessionID, data core.Frame) {
 	roomID, _, ok := o.Registry.RoomOf(sid)
 	if !ok {
@@ -47,7 +86,7 @@ func (o *Orchestrator) OnFrame(sid core.SessionID, data core.Frame) {
 		case app.KickMember:
 			for _, snap := range o.Registry.MembersOfRoom(roomID) {
 				if snap.Session == slow {
-					o.KickBySID(snap.SID)
+					o.kickOut(room, snap.SID, core.KickedSlow, "", nil)
 				}
 			}
 		case app.MarkSlow, app.DropFrame, app.NoAction:
@@ -0,0 +1,102 @@
+package orch
+
+import (
+	"context"
+	"time"
+
+	"github.com/dkeye/Voice/internal/app"
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultInviteTTL applies when an invite is requested without a lifetime.
+const DefaultInviteTTL = 24 * time.Hour
+
+// Authorize asks the Authorizer whether sid may act on a room. On create
+// only name is known. Without an Authorizer everything is allowed.
+func (o *Orchestrator) Authorize(sid core.SessionID, action core.AccessAction, room domain.RoomID, name domain.RoomName) error {
+	if o.Authorizer == nil {
+		return nil
+	}
+	prof, err := o.Registry.Profile(sid)
+	if err != nil {
+		return ErrNoSession
+	}
+	d, err := o.Authorizer.Authorize(context.Background(), core.AccessRequest{
+		Action:   action,
+		User:     domain.User{ID: prof.ID, Username: prof.Username},
+		Login:    prof.Login,
+		Roles:    prof.Roles,
+		Room:     room,
+		RoomName: name,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("module", "orch").Str("sid", string(sid)).Str("action", string(action)).Msg("authorization failed")
+		return &DeniedError{Reason: "authorization unavailable"}
+	}
+	if !d.Allow {
+		log.Info().Str("module", "orch").Str("sid", string(sid)).Str("action", string(action)).Str("room_id", string(room)).Str("reason", d.Reason).Msg("access denied")
+		return &DeniedError{Reason: d.Reason}
+	}
+	return nil
+}
+
+// Admit checks whether sid may enter room with the given password or invite
+// token. Moderators always pass; a valid invite also bypasses the password.
+// The invite is only checked here; RedeemInvite spends it once the join has
+// gone through.
+func (o *Orchestrator) Admit(sid core.SessionID, room core.RoomService, password, invite string) error {
+	settings := room.Room().Settings
+	if !room.Room().IsLocked() {
+		return nil
+	}
+	user, err := o.Registry.GetOrCreateUser(sid)
+	if err != nil {
+		return ErrNoSession
+	}
+	if room.IsModerator(user.ID) {
+		return nil
+	}
+	if invite != "" {
+		_, err := o.Invites.Check(room.Room().ID, invite)
+		return err
+	}
+	if settings.InviteOnly {
+		return ErrInviteRequired
+	}
+	return app.CheckRoomPassword(settings.PasswordHash, password)
+}
+
+// RedeemInvite spends one use of the invite sid got into room with. It is a
+// no-op when Admit let sid in without the invite.
+func (o *Orchestrator) RedeemInvite(sid core.SessionID, room core.RoomService, invite string) error {
+	if invite == "" || !room.Room().IsLocked() {
+		return nil
+	}
+	user, err := o.Registry.GetOrCreateUser(sid)
+	if err != nil {
+		return ErrNoSession
+	}
+	if room.IsModerator(user.ID) {
+		return nil
+	}
+	return o.Invites.Redeem(room.Room().ID, invite)
+}
+
+// CreateInvite issues an invite token for sid's room. Only moderators may.
+func (o *Orchestrator) CreateInvite(sid core.SessionID, ttl time.Duration, maxUses int) (string, app.Invite, error) {
+	room, self, err := o.currentRoom(sid)
+	if err != nil {
+		return "", app.Invite{}, err
+	}
+	if !room.IsModerator(self.ID) {
+		return "", app.Invite{}, ErrNotModerator
+	}
+	if ttl <= 0 {
+		ttl = DefaultInviteTTL
+	}
+	token, inv := o.Invites.Issue(room.Room().ID, ttl, maxUses)
+	log.Info().Str("module", "orch").Str("sid", string(sid)).Str("room_id", string(inv.Room)).Msg("invite created")
+	return token, inv, nil
+}
@@ -0,0 +1,94 @@
+package orch
+
+import (
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// Ban keeps target out of sid's room for d, or for good when d is zero.
+// byIP also bans the address target is connected from, which needs target
+// to be in the room. A present target is removed and told why; the
+// returned session is empty otherwise.
+func (o *Orchestrator) Ban(sid core.SessionID, target domain.UserID, note string, d time.Duration, byIP bool) (core.RoomService, core.SessionID, error) {
+	room, self, err := o.currentRoom(sid)
+	if err != nil {
+		return nil, "", err
+	}
+	if !self.RoomRole.CanModerate() {
+		return nil, "", ErrNotModerator
+	}
+	if target == self.ID || room.RoleOf(target).Rank() >= self.RoomRole.Rank() {
+		return nil, "", ErrOutranked
+	}
+	targetSID, present := room.SessionOf(target)
+	ban := newBan(self.ID, target, note, d)
+	if byIP {
+		ms, ok := room.Member(targetSID)
+		if !present || !ok {
+			return nil, "", ErrNoSuchMember
+		}
+		ban.IP, ban.ByIP = ms.Meta().ClientIP, true
+	}
+	room.Ban(ban)
+	// Rights do not survive a ban.
+	room.GrantRole(target, domain.RoleMember)
+	log.Info().Str("module", "orch").Str("sid", string(sid)).Str("target", string(target)).Dur("for", d).Bool("ip", byIP).Msg("user banned")
+	if !present {
+		return room, "", nil
+	}
+	o.kickOut(room, targetSID, core.KickedBanned, note, ban.Until)
+	return room, targetSID, nil
+}
+
+// Unban lifts the ban of target in sid's room.
+func (o *Orchestrator) Unban(sid core.SessionID, target domain.UserID) error {
+	room, self, err := o.currentRoom(sid)
+	if err != nil {
+		return err
+	}
+	if !self.RoomRole.CanModerate() {
+		return ErrNotModerator
+	}
+	if !room.Unban(target) {
+		return ErrNotBanned
+	}
+	log.Info().Str("module", "orch").Str("sid", string(sid)).Str("target", string(target)).Msg("user unbanned")
+	return nil
+}
+
+// Bans lists the active bans of sid's room for its moderators.
+func (o *Orchestrator) Bans(sid core.SessionID) ([]domain.Ban, error) {
+	room, self, err := o.currentRoom(sid)
+	if err != nil {
+		return nil, err
+	}
+	if !self.RoomRole.CanModerate() {
+		return nil, ErrNotModerator
+	}
+	return room.Bans(time.Now()), nil
+}
+
+// kickOut removes sid from room and tells the session why.
+func (o *Orchestrator) kickOut(room core.RoomService, sid core.SessionID, reason core.KickReason, note string, until *time.Time) {
+	o.KickBySID(sid)
+	o.publish(core.MemberKicked{
+		Room:   room.Room().ID,
+		SID:    sid,
+		Reason: reason,
+		Note:   note,
+		Until:  until,
+	})
+}
+
+func newBan(by, target domain.UserID, note string, d time.Duration) domain.Ban {
+	now := time.Now().UTC()
+	ban := domain.Ban{User: target, Reason: note, By: by, At: now}
+	if d > 0 {
+		until := now.Add(d)
+		ban.Until = &until
+	}
+	return ban
+}
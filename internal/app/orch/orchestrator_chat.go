@@ -0,0 +1,70 @@
+package orch
+
+import (
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// SendChat posts text from sid to its room's chat.
+func (o *Orchestrator) SendChat(sid core.SessionID, text string) (core.RoomService, domain.ChatMessage, error) {
+	room, _, err := o.currentRoom(sid)
+	if err != nil {
+		return nil, domain.ChatMessage{}, err
+	}
+	user, err := o.Registry.GetOrCreateUser(sid)
+	if err != nil {
+		return nil, domain.ChatMessage{}, ErrNoSession
+	}
+	msg, err := domain.NewChatMessage(*user, text)
+	if err != nil {
+		return nil, domain.ChatMessage{}, err
+	}
+	room.PostChat(*msg, o.ChatHistory)
+	return room, *msg, nil
+}
+
+// EditChat changes the text of a message. Only its author and moderators may.
+func (o *Orchestrator) EditChat(sid core.SessionID, id domain.ChatMessageID, text string) (core.RoomService, domain.ChatMessage, error) {
+	if err := domain.ValidateChatText(text); err != nil {
+		return nil, domain.ChatMessage{}, err
+	}
+	room, err := o.chatMessageOwned(sid, id)
+	if err != nil {
+		return nil, domain.ChatMessage{}, err
+	}
+	msg, ok := room.EditChat(id, text)
+	if !ok {
+		return nil, domain.ChatMessage{}, ErrNoSuchMessage
+	}
+	return room, msg, nil
+}
+
+// DeleteChat removes a message from the history. Only its author and moderators may.
+func (o *Orchestrator) DeleteChat(sid core.SessionID, id domain.ChatMessageID) (core.RoomService, error) {
+	room, err := o.chatMessageOwned(sid, id)
+	if err != nil {
+		return nil, err
+	}
+	if !room.DeleteChat(id) {
+		return nil, ErrNoSuchMessage
+	}
+	return room, nil
+}
+
+// chatMessageOwned resolves sid's room and checks that sid may change message id.
+func (o *Orchestrator) chatMessageOwned(sid core.SessionID, id domain.ChatMessageID) (core.RoomService, error) {
+	room, self, err := o.currentRoom(sid)
+	if err != nil {
+		return nil, err
+	}
+	msg, ok := room.ChatMessage(id)
+	if !ok {
+		return nil, ErrNoSuchMessage
+	}
+	if msg.Author.ID != self.ID && !room.IsModerator(self.ID) {
+		log.Warn().Str("module", "orch").Str("sid", string(sid)).Str("msg", string(id)).Msg("chat change denied")
+		return nil, ErrNotModerator
+	}
+	return room, nil
+}
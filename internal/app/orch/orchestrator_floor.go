@@ -0,0 +1,132 @@
+package orch
+
+import (
+	"github.com/dkeye/Voice/internal/app"
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// RequestFloor asks for the push-to-talk floor; sid is queued if it is taken.
+// It reports whether sid holds the floor now.
+func (o *Orchestrator) RequestFloor(sid core.SessionID) (bool, error) {
+	_, self, floor, err := o.floorOf(sid)
+	if err != nil {
+		return false, err
+	}
+	if !self.Role.CanSpeak() {
+		return false, ErrAudience
+	}
+	return floor.Request(sid), nil
+}
+
+// ReleaseFloor gives up the floor or leaves the queue.
+func (o *Orchestrator) ReleaseFloor(sid core.SessionID) error {
+	_, _, floor, err := o.floorOf(sid)
+	if err != nil {
+		return err
+	}
+	floor.Release(sid)
+	return nil
+}
+
+// GrantFloor is a moderator override that hands the floor to target at once.
+func (o *Orchestrator) GrantFloor(sid core.SessionID, target domain.UserID) error {
+	room, self, floor, err := o.floorOf(sid)
+	if err != nil {
+		return err
+	}
+	if !room.IsModerator(self.ID) {
+		return ErrNotModerator
+	}
+	targetSID, ok := room.SessionOf(target)
+	if !ok {
+		return ErrNoSuchMember
+	}
+	member, ok := room.MemberDTO(targetSID)
+	if !ok {
+		return ErrNoSuchMember
+	}
+	// The override skips the queue, not the rules of who may speak.
+	if !member.Role.CanSpeak() {
+		return ErrAudience
+	}
+	floor.Grant(targetSID)
+	return nil
+}
+
+// RevokeFloor is a moderator override that takes the floor from its holder.
+func (o *Orchestrator) RevokeFloor(sid core.SessionID) error {
+	room, self, floor, err := o.floorOf(sid)
+	if err != nil {
+		return err
+	}
+	if !room.IsModerator(self.ID) {
+		return ErrNotModerator
+	}
+	floor.Revoke()
+	return nil
+}
+
+// FloorState returns the current floor of a floor-controlled room.
+func (o *Orchestrator) FloorState(room core.RoomService) (core.FloorChanged, bool) {
+	if !room.Room().Settings.FloorControl {
+		return core.FloorChanged{}, false
+	}
+	return o.floorEvent(room, o.roomFloor(room).State(), ""), true
+}
+
+func (o *Orchestrator) floorOf(sid core.SessionID) (core.RoomService, core.MemberDTO, *app.Floor, error) {
+	room, self, err := o.currentRoom(sid)
+	if err != nil {
+		return nil, self, nil, err
+	}
+	if !room.Room().Settings.FloorControl {
+		return nil, self, nil, ErrNoFloorControl
+	}
+	return room, self, o.roomFloor(room), nil
+}
+
+func (o *Orchestrator) roomFloor(room core.RoomService) *app.Floor {
+	maxHold := room.Room().Settings.FloorMaxHold
+	if maxHold == 0 {
+		maxHold = o.FloorMaxHold
+	}
+	roomID := room.Room().ID
+	return o.Floors.GetOrCreate(roomID, maxHold, func(state app.FloorState, reason core.FloorReason) {
+		o.onFloorChange(roomID, state, reason)
+	})
+}
+
+func (o *Orchestrator) onFloorChange(roomID domain.RoomID, state app.FloorState, reason core.FloorReason) {
+	room, ok := o.Rooms.GetRoom(roomID)
+	if !ok {
+		return
+	}
+	log.Info().
+		Str("module", "orch").
+		Str("room_id", string(roomID)).
+		Str("holder", string(state.Holder)).
+		Int("queue", len(state.Queue)).
+		Str("reason", string(reason)).
+		Msg("floor changed")
+	o.refreshForwarding(roomID)
+	o.publish(o.floorEvent(room, state, reason))
+}
+
+func (o *Orchestrator) floorEvent(room core.RoomService, state app.FloorState, reason core.FloorReason) core.FloorChanged {
+	ev := core.FloorChanged{
+		Room:   room.Room().ID,
+		Queue:  make([]core.MemberDTO, 0, len(state.Queue)),
+		Reason: reason,
+	}
+	if dto, ok := room.MemberDTO(state.Holder); ok {
+		ev.Holder = &dto
+	}
+	for _, sid := range state.Queue {
+		if dto, ok := room.MemberDTO(sid); ok {
+			ev.Queue = append(ev.Queue, dto)
+		}
+	}
+	return ev
+}
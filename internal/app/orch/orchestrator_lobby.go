@@ -0,0 +1,48 @@
+package orch
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+)
+
+// CreateRoom creates a room and announces it in the lobby if it is public.
+// The room size is clamped to Limits.RoomMembers.
+func (o *Orchestrator) CreateRoom(name domain.RoomName, settings domain.RoomSettings) core.RoomService {
+	settings.MaxMembers = o.Limits.roomSize(settings.MaxMembers)
+	room := o.Rooms.CreateRoom(name, settings)
+	o.publishRoomList(room, core.RoomListCreated)
+	return room
+}
+
+// CheckPersistent reports whether sid may create a persistent room. Such
+// rooms outlive restarts and the janitor, so only admins get to make them.
+func (o *Orchestrator) CheckPersistent(sid core.SessionID) error {
+	prof, err := o.Registry.Profile(sid)
+	if err != nil {
+		return ErrNoSession
+	}
+	if o.AdminRole == "" || !slices.Contains(prof.Roles, o.AdminRole) {
+		return ErrNotAdmin
+	}
+	return nil
+}
+
+// PublicRooms lists the rooms shown in the lobby, ordered by name.
+func (o *Orchestrator) PublicRooms() []core.RoomInfo {
+	rooms := slices.DeleteFunc(o.Rooms.List(), func(r core.RoomInfo) bool { return !r.Public })
+	slices.SortFunc(rooms, func(a, b core.RoomInfo) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return rooms
+}
+
+// publishRoomList tells lobby subscribers about a change to a public room.
+func (o *Orchestrator) publishRoomList(room core.RoomService, change core.RoomListChange) {
+	if !room.Room().IsPublic() {
+		return
+	}
+	o.publish(core.RoomListChanged{Room: core.InfoOf(room), Change: change})
+}
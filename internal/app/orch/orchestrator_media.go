@@ -2,8 +2,11 @@ package orch
 
 import (
 	"context"
+	"slices"
 
+	"github.com/dkeye/Voice/internal/app/sfu"
 	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
 	"github.com/pion/webrtc/v4"
 	"github.com/rs/zerolog/log"
 )
@@ -12,16 +15,38 @@ func (o *Orchestrator) BindMediaHandlers(mc core.MediaConnection, sid core.Sessi
 	mc.OnTrack(func(trackCtx context.Context, track *webrtc.TrackRemote, receiver *webrtc.RTPReceiver) {
 		o.OnTrack(trackCtx, sid, track)
 	})
-	mc.OnClosed(func() { o.OnMediaDisconnect(sid) })
+	mc.OnQualityChange(func(q domain.ConnQuality) { o.onQuality(sid, mc, q) })
+	mc.OnClosed(func() { o.onMediaClosed(sid, mc) })
 }
 
 func (o *Orchestrator) OnMediaDisconnect(sid core.SessionID) {
 	o.cleanupMedia(sid)
 }
 
+// onMediaClosed cleans up after mc unless the session has moved on to
+// another connection in the meantime.
+func (o *Orchestrator) onMediaClosed(sid core.SessionID, mc core.MediaConnection) {
+	if sess, ok := o.Registry.GetSession(sid); ok && sess.Media() != nil && sess.Media() != mc {
+		log.Info().Str("module", "orch").Str("sid", string(sid)).Msg("stale media connection closed")
+		return
+	}
+	o.OnMediaDisconnect(sid)
+}
+
+// Renegotiate has the server re-offer on sid's media connection, if any.
+func (o *Orchestrator) Renegotiate(sid core.SessionID) {
+	sess, ok := o.Registry.GetSession(sid)
+	if !ok {
+		return
+	}
+	if mc := sess.Media(); mc != nil && !mc.IsClosed() {
+		mc.Renegotiate()
+	}
+}
+
 func (o *Orchestrator) cleanupMedia(sid core.SessionID) {
 	if o.Relays != nil {
-		o.Relays.StopRelay(sid)
+		o.stopPublishing(sid)
 
 		for _, snap := range o.Registry.RoomMates(sid) {
 			o.Relays.MarkSubscriberDelete(snap.SID, sid)
@@ -34,6 +59,134 @@ func (o *Orchestrator) cleanupMedia(sid core.SessionID) {
 			sess.UpdateMedia(nil)
 		}
 	}
+	o.updateOwnMember(sid, func(m *domain.Member) {
+		m.MediaConnected = false
+		m.Quality = ""
+	})
+}
+
+// stopPublishing stops forwarding sid's upstream audio while keeping its connection.
+func (o *Orchestrator) stopPublishing(sid core.SessionID) {
+	if o.Relays == nil {
+		return
+	}
+	o.Relays.StopRelay(sid)
+}
+
+// SetDeafened stops or resumes forwarding every relay in the room to sid.
+func (o *Orchestrator) SetDeafened(sid core.SessionID, deafened bool) (core.RoomService, error) {
+	room, _, err := o.currentRoom(sid)
+	if err != nil {
+		return nil, err
+	}
+	o.updateMember(room, sid, func(m *domain.Member) { m.Deafened = deafened })
+	o.refreshForwarding(room.Room().ID)
+	return room, nil
+}
+
+// refreshForwarding recomputes the mute state of every relay in the room.
+// It must run after anything that changes who may be heard. New
+// subscriptions start with their reasons already set from forwardingOf.
+func (o *Orchestrator) refreshForwarding(roomID domain.RoomID) {
+	if o.Relays == nil {
+		return
+	}
+	room, ok := o.Rooms.GetRoom(roomID)
+	if !ok {
+		return
+	}
+	f := o.forwardingOf(room)
+	members := o.Registry.MembersOfRoom(roomID)
+	for _, src := range members {
+		for _, reason := range []sfu.MuteReason{sfu.MuteFloor, sfu.MuteModerator, sfu.MuteStage} {
+			o.Relays.SetSourceMuted(src.SID, reason, f.sourceMute(src.SID)&reason != 0)
+		}
+		for _, dst := range members {
+			if dst.SID == src.SID {
+				continue
+			}
+			mute := f.subscriberMute(src.SID, dst.SID)
+			o.Relays.SetSubscriberMuted(src.SID, dst.SID, sfu.MuteDeafen, mute&sfu.MuteDeafen != 0)
+			o.Relays.SetSubscriberMuted(src.SID, dst.SID, sfu.MuteWhisper, mute&sfu.MuteWhisper != 0)
+		}
+	}
+}
+
+// forwarding is who may hear whom in a room at one moment.
+type forwarding struct {
+	floored  bool
+	holder   core.SessionID
+	deafened map[core.SessionID]bool
+	silenced map[core.SessionID]bool
+	audience map[core.SessionID]bool
+	whispers map[core.SessionID][]core.SessionID
+}
+
+func (o *Orchestrator) forwardingOf(room core.RoomService) forwarding {
+	f := forwarding{
+		floored:  room.Room().Settings.FloorControl,
+		deafened: make(map[core.SessionID]bool),
+		silenced: make(map[core.SessionID]bool),
+		audience: make(map[core.SessionID]bool),
+		whispers: room.Whispers(),
+	}
+	if f.floored {
+		f.holder = o.roomFloor(room).State().Holder
+	}
+	for _, snap := range o.Registry.MembersOfRoom(room.Room().ID) {
+		if dto, ok := room.MemberDTO(snap.SID); ok {
+			f.deafened[snap.SID] = dto.Deafened
+			f.silenced[snap.SID] = dto.ForceMuted
+			f.audience[snap.SID] = !dto.Role.CanSpeak()
+		}
+	}
+	return f
+}
+
+// sourceMute is what holds everything src sends.
+func (f forwarding) sourceMute(src core.SessionID) sfu.MuteReason {
+	var m sfu.MuteReason
+	if f.floored && src != f.holder {
+		m |= sfu.MuteFloor
+	}
+	if f.silenced[src] {
+		m |= sfu.MuteModerator
+	}
+	if f.audience[src] {
+		m |= sfu.MuteStage
+	}
+	return m
+}
+
+// subscriberMute is what holds src's audio on its way to dst alone.
+func (f forwarding) subscriberMute(src, dst core.SessionID) sfu.MuteReason {
+	var m sfu.MuteReason
+	if f.deafened[dst] {
+		m |= sfu.MuteDeafen
+	}
+	if targets, ok := f.whispers[src]; ok && !slices.Contains(targets, dst) {
+		m |= sfu.MuteWhisper
+	}
+	return m
+}
+
+// muteFor is every reason src must not reach dst right now.
+func (f forwarding) muteFor(src, dst core.SessionID) sfu.MuteReason {
+	return f.sourceMute(src) | f.subscriberMute(src, dst)
+}
+
+// canPublish reports whether sid may send upstream audio in its current room.
+func (o *Orchestrator) canPublish(sid core.SessionID) bool {
+	roomID, _, ok := o.Registry.RoomOf(sid)
+	if !ok {
+		return true
+	}
+	room, ok := o.Rooms.GetRoom(roomID)
+	if !ok {
+		return true
+	}
+	self, ok := room.MemberDTO(sid)
+	return !ok || self.Role.CanSpeak()
 }
 
 // OnTrack is called when a new remote media track appears for a given session.
@@ -44,8 +197,31 @@ func (o *Orchestrator) OnTrack(ctx context.Context, sid core.SessionID, track *w
 	if sess, ok := o.Registry.GetSession(sid); !ok || sess.Media() == nil {
 		return
 	}
+	if !o.canPublish(sid) {
+		log.Info().
+			Str("module", "sfu").
+			Str("sid", string(sid)).
+			Msg("OnTrack: upstream track from audience member parked")
+		o.Relays.Park(ctx, sid, track)
+		return
+	}
+	o.startPublishing(ctx, sid, track)
+}
+
+// startPublishing starts the relay of sid's upstream track and subscribes
+// the rest of the room to it.
+func (o *Orchestrator) startPublishing(ctx context.Context, sid core.SessionID, track *webrtc.TrackRemote) {
+	if !o.Relays.HasRelay(sid) && o.Limits.MaxRelays > 0 && o.Relays.Count() >= o.Limits.MaxRelays {
+		log.Warn().Str("module", "sfu").Str("sid", string(sid)).Msg("OnTrack: relay limit reached, track ignored")
+		return
+	}
 	o.Relays.StartRelay(ctx, sid, track)
 
+	if o.Registry.IsEcho(sid) {
+		o.subscribeEcho(sid, track)
+		return
+	}
+
 	roomID, _, ok := o.Registry.RoomOf(sid)
 	if !ok {
 		log.Info().
@@ -55,6 +231,11 @@ func (o *Orchestrator) OnTrack(ctx context.Context, sid core.SessionID, track *w
 		return
 	}
 
+	room, ok := o.Rooms.GetRoom(roomID)
+	if !ok {
+		return
+	}
+	f := o.forwardingOf(room)
 	// Subscribe all existing members in the room to this speaker.
 	for _, snap := range o.Registry.MembersOfRoom(roomID) {
 		if snap.SID == sid {
@@ -64,7 +245,7 @@ func (o *Orchestrator) OnTrack(ctx context.Context, sid core.SessionID, track *w
 		if mc == nil || mc.IsClosed() {
 			continue
 		}
-		if err := o.Relays.Subscribe(sid, snap.SID, mc, track); err != nil {
+		if err := o.Relays.Subscribe(sid, snap.SID, mc, track, f.muteFor(sid, snap.SID)); err != nil {
 			log.Error().
 				Err(err).
 				Str("module", "sfu").
@@ -74,19 +255,32 @@ func (o *Orchestrator) OnTrack(ctx context.Context, sid core.SessionID, track *w
 			continue
 		}
 	}
+	o.refreshForwarding(roomID)
 }
 
-// OnMediaReady is called when MediaConnection is attached to the session (offer/answer done).
-// It subscribes this user as a subscriber to all existing relays in the same room.
-func (o *Orchestrator) OnMediaReady(sid core.SessionID) {
-	if o.Relays == nil {
+// subscribeEcho wires sid's relay back to its own connection with EchoDelay.
+func (o *Orchestrator) subscribeEcho(sid core.SessionID, track *webrtc.TrackRemote) {
+	sess, ok := o.Registry.GetSession(sid)
+	if !ok {
 		return
 	}
-	roomID, _, ok := o.Registry.RoomOf(sid)
-	if !ok {
+	mc := sess.Media()
+	if mc == nil || mc.IsClosed() {
 		return
 	}
+	if err := o.Relays.SubscribeDelayed(sid, sid, mc, track, o.EchoDelay); err != nil {
+		log.Error().
+			Err(err).
+			Str("module", "sfu").
+			Str("sid", string(sid)).
+			Msg("Subscribe echo failed")
+	}
+}
 
+// OnMediaReady is called when MediaConnection is attached to the session (offer/answer done).
+// It marks the member's media as connected and subscribes this user as a
+// subscriber to all existing relays in the same room.
+func (o *Orchestrator) OnMediaReady(sid core.SessionID) {
 	// If there is no media connection yet, nothing to do.
 	sess, ok := o.Registry.GetSession(sid)
 	if !ok {
@@ -96,7 +290,21 @@ func (o *Orchestrator) OnMediaReady(sid core.SessionID) {
 	if mc == nil || mc.IsClosed() {
 		return
 	}
+	o.updateOwnMember(sid, func(m *domain.Member) { m.MediaConnected = true })
+
+	if o.Relays == nil {
+		return
+	}
+	roomID, _, ok := o.Registry.RoomOf(sid)
+	if !ok {
+		return
+	}
 
+	room, ok := o.Rooms.GetRoom(roomID)
+	if !ok {
+		return
+	}
+	f := o.forwardingOf(room)
 	for _, snap := range o.Registry.MembersOfRoom(roomID) {
 		if snap.SID == sid {
 			continue
@@ -105,7 +313,7 @@ func (o *Orchestrator) OnMediaReady(sid core.SessionID) {
 		if !ok {
 			continue
 		}
-		if err := o.Relays.Subscribe(snap.SID, sid, mc, track); err != nil {
+		if err := o.Relays.Subscribe(snap.SID, sid, mc, track, f.muteFor(snap.SID, sid)); err != nil {
 			log.Error().
 				Err(err).
 				Str("module", "sfu").
@@ -116,4 +324,5 @@ func (o *Orchestrator) OnMediaReady(sid core.SessionID) {
 		}
 
 	}
+	o.refreshForwarding(roomID)
 }
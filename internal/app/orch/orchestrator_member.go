@@ -0,0 +1,110 @@
+package orch
+
+import (
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// updateMember is the one path for changing a member's presence state: it
+// applies fn inside the room and publishes the resulting diff, if any.
+func (o *Orchestrator) updateMember(room core.RoomService, sid core.SessionID, fn func(m *domain.Member)) (core.MemberDTO, bool) {
+	before, after, ok := room.UpdateMember(sid, fn)
+	if !ok {
+		return core.MemberDTO{}, false
+	}
+	o.announceMember(room, before, after)
+	return after, true
+}
+
+// announceMember publishes the diff between two views of a member, if any.
+func (o *Orchestrator) announceMember(room core.RoomService, before, after core.MemberDTO) {
+	if changes, changed := core.DiffMember(before, after); changed {
+		o.publish(core.MemberChanged{
+			Room:    room.Room().ID,
+			User:    domain.User{ID: after.ID, Username: after.Username},
+			Changes: changes,
+		})
+	}
+}
+
+// Rename changes the username of sid's user. Every device of the user
+// shares the name, so each room one of them is in gets the diff.
+func (o *Orchestrator) Rename(sid core.SessionID, name string) error {
+	cid, ok := o.Registry.ClientOf(sid)
+	if !ok {
+		return ErrNoSession
+	}
+	if err := o.Registry.UpdateUsername(sid, name); err != nil {
+		return err
+	}
+	o.userChanged(cid)
+	return nil
+}
+
+// Identify applies an externally issued identity to the registry. A name
+// the issuer changed is announced like a rename.
+func (o *Orchestrator) Identify(id core.Identity) error {
+	if _, err := o.Registry.Identify(id); err != nil {
+		return err
+	}
+	o.userChanged(id.Client)
+	return nil
+}
+
+// userChanged hands the current user of cid to the members of its sessions
+// and announces the diff in each room. The user is swapped under the room
+// lock, so readers of the member never see it half-written.
+func (o *Orchestrator) userChanged(cid core.ClientID) {
+	user, err := o.Registry.ClientUser(cid)
+	if err != nil {
+		return
+	}
+	for _, sid := range o.Registry.SessionsOf(cid) {
+		roomID, _, ok := o.Registry.RoomOf(sid)
+		if !ok {
+			continue
+		}
+		if room, ok := o.Rooms.GetRoom(roomID); ok {
+			o.updateMember(room, sid, func(m *domain.Member) {
+				// The room knows the member by its ID; a new one only
+				// applies from the next join.
+				if m.User.ID == user.ID {
+					m.User = user
+				}
+			})
+		}
+	}
+}
+
+// updateOwnMember applies fn to sid's state in whatever room it is in.
+// Sessions outside a room have nothing to announce and are skipped.
+func (o *Orchestrator) updateOwnMember(sid core.SessionID, fn func(m *domain.Member)) {
+	roomID, _, ok := o.Registry.RoomOf(sid)
+	if !ok {
+		return
+	}
+	if room, ok := o.Rooms.GetRoom(roomID); ok {
+		o.updateMember(room, sid, fn)
+	}
+}
+
+// SetMuted records the member's own microphone switch.
+func (o *Orchestrator) SetMuted(sid core.SessionID, muted bool) error {
+	room, _, err := o.currentRoom(sid)
+	if err != nil {
+		return err
+	}
+	o.updateMember(room, sid, func(m *domain.Member) { m.Mute = muted })
+	return nil
+}
+
+// onQuality rates sid's media connection. Reports from a connection that
+// has since been replaced are dropped.
+func (o *Orchestrator) onQuality(sid core.SessionID, mc core.MediaConnection, q domain.ConnQuality) {
+	if sess, ok := o.Registry.GetSession(sid); !ok || sess.Media() != mc {
+		return
+	}
+	log.Debug().Str("module", "orch").Str("sid", string(sid)).Str("quality", string(q)).Msg("media quality")
+	o.updateOwnMember(sid, func(m *domain.Member) { m.Quality = q })
+}
@@ -0,0 +1,133 @@
+package orch
+
+import (
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// Kick takes target out of sid's room and tells it why. With a
+// KickCooldown the target is also banned for that long, so a kick is not
+// undone by rejoining straight away.
+func (o *Orchestrator) Kick(sid core.SessionID, target domain.UserID, note string) (core.RoomService, core.SessionID, error) {
+	room, targetSID, err := o.moderate(sid, target)
+	if err != nil {
+		return nil, "", err
+	}
+	var until *time.Time
+	if o.KickCooldown > 0 {
+		self, _ := room.MemberDTO(sid)
+		ban := newBan(self.ID, target, note, o.KickCooldown)
+		room.Ban(ban)
+		until = ban.Until
+	}
+	log.Info().Str("module", "orch").Str("sid", string(sid)).Str("target_sid", string(targetSID)).Msg("member kicked")
+	o.kickOut(room, targetSID, core.KickedByModerator, note, until)
+	return room, targetSID, nil
+}
+
+// MuteMember sets or clears a moderator mute on target. While set, the
+// server forwards none of the target's audio whatever its own switch says.
+func (o *Orchestrator) MuteMember(sid core.SessionID, target domain.UserID, muted bool) (core.RoomService, error) {
+	room, targetSID, err := o.moderate(sid, target)
+	if err != nil {
+		return nil, err
+	}
+	o.updateMember(room, targetSID, func(m *domain.Member) { m.ForceMuted = muted })
+	o.refreshForwarding(room.Room().ID)
+	return room, nil
+}
+
+// SetRoomRole makes target a moderator or a plain member. Only the owner
+// may change room roles; ownership itself moves with TransferOwnership.
+func (o *Orchestrator) SetRoomRole(sid core.SessionID, target domain.UserID, role domain.RoomRole) (core.RoomService, error) {
+	if role != domain.RoleModerator && role != domain.RoleMember {
+		return nil, ErrBadRole
+	}
+	room, targetSID, err := o.ownerTarget(sid, target)
+	if err != nil {
+		return nil, err
+	}
+	o.updateMember(room, targetSID, func(m *domain.Member) { m.RoomRole = role })
+	return room, nil
+}
+
+// TransferOwnership hands the room to target; the old owner stays on as a
+// moderator.
+func (o *Orchestrator) TransferOwnership(sid core.SessionID, target domain.UserID) (core.RoomService, error) {
+	room, targetSID, err := o.ownerTarget(sid, target)
+	if err != nil {
+		return nil, err
+	}
+	o.updateMember(room, sid, func(m *domain.Member) { m.RoomRole = domain.RoleModerator })
+	o.updateMember(room, targetSID, func(m *domain.Member) { m.RoomRole = domain.RoleOwner })
+	log.Info().Str("module", "orch").Str("sid", string(sid)).Str("target_sid", string(targetSID)).Msg("ownership transferred")
+	return room, nil
+}
+
+// CloseRoom authorizes the owner to close the room; the caller then runs
+// EvictRoom.
+func (o *Orchestrator) CloseRoom(sid core.SessionID) (core.RoomService, error) {
+	room, self, err := o.currentRoom(sid)
+	if err != nil {
+		return nil, err
+	}
+	if self.RoomRole != domain.RoleOwner {
+		return nil, ErrNotOwner
+	}
+	return room, nil
+}
+
+// handOverOwnership passes the room to its successor after the owner left.
+// The old owner keeps moderator rights should they come back.
+func (o *Orchestrator) handOverOwnership(room core.RoomService, former domain.UserID) {
+	next, ok := room.Successor()
+	if !ok {
+		return
+	}
+	room.GrantRole(former, domain.RoleModerator)
+	o.updateMember(room, next, func(m *domain.Member) { m.RoomRole = domain.RoleOwner })
+	log.Info().Str("module", "orch").Str("room_id", string(room.Room().ID)).Str("owner_sid", string(next)).Msg("ownership handed over")
+}
+
+// moderate resolves target in sid's room, requiring sid to be a moderator
+// who outranks the target.
+func (o *Orchestrator) moderate(sid core.SessionID, target domain.UserID) (core.RoomService, core.SessionID, error) {
+	room, self, err := o.currentRoom(sid)
+	if err != nil {
+		return nil, "", err
+	}
+	if !self.RoomRole.CanModerate() {
+		return nil, "", ErrNotModerator
+	}
+	targetSID, ok := room.SessionOf(target)
+	if !ok {
+		return nil, "", ErrNoSuchMember
+	}
+	if room.RoleOf(target).Rank() >= self.RoomRole.Rank() {
+		return nil, "", ErrOutranked
+	}
+	return room, targetSID, nil
+}
+
+// ownerTarget resolves target in sid's room, requiring sid to be the owner
+// and target to be someone else.
+func (o *Orchestrator) ownerTarget(sid core.SessionID, target domain.UserID) (core.RoomService, core.SessionID, error) {
+	room, self, err := o.currentRoom(sid)
+	if err != nil {
+		return nil, "", err
+	}
+	if self.RoomRole != domain.RoleOwner {
+		return nil, "", ErrNotOwner
+	}
+	if target == self.ID {
+		return nil, "", ErrOutranked
+	}
+	targetSID, ok := room.SessionOf(target)
+	if !ok {
+		return nil, "", ErrNoSuchMember
+	}
+	return room, targetSID, nil
+}
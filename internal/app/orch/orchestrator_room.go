@@ -1,27 +1,150 @@
 package orch
 
 import (
+	"time"
+
 	"github.com/dkeye/Voice/internal/core"
 	"github.com/dkeye/Voice/internal/domain"
 	"github.com/rs/zerolog/log"
 )
 
-func (o *Orchestrator) Join(sid core.SessionID, roomID domain.RoomID) {
+func (o *Orchestrator) Join(sid core.SessionID, roomID domain.RoomID) error {
+	o.refreshUser(sid)
+	room, session, err := o.joinable(sid, roomID)
+	if err != nil {
+		return err
+	}
+	meta := session.Meta()
+	// Per-room state starts fresh; the member is not visible to anyone yet.
+	meta.StageRole = ""
+	meta.HandRaised = false
+	meta.ForceMuted = false
+	if room.Room().IsStage() {
+		meta.StageRole = initialStageRole(room, meta.User.ID)
+	}
+	meta.MediaConnected = session.Media() != nil
+	if !room.AddMember(sid, session) {
+		// Someone took the last place, or the room closed, since joinable.
+		if phase, _ := room.Phase(); phase == domain.RoomClosed {
+			return ErrRoomNotFound
+		}
+		log.Info().Str("module", "orch").Str("sid", string(sid)).Str("room_id", string(roomID)).Msg("room full")
+		return ErrRoomFull
+	}
+	o.Registry.UpdateRoom(sid, roomID)
+	o.publishRoomList(room, core.RoomListUpdated)
+	log.Info().Str("sid", string(sid)).Str("room_id", string(roomID)).Msg("added to room")
+	return nil
+}
+
+// Move hands the membership of from, another session of the same user in
+// roomID, to sid. The member stays in the room throughout: its roles, mute
+// and whispers carry over, and nobody sees it leave or join. Only its media
+// goes, since that belongs to the old device. The from session stays bound
+// but is no longer in a room.
+func (o *Orchestrator) Move(from, sid core.SessionID, roomID domain.RoomID) error {
+	o.refreshUser(sid)
+	room, session, err := o.joinable(sid, roomID)
+	if err != nil {
+		return err
+	}
+	if cur, _, ok := o.Registry.RoomOf(from); !ok || cur != roomID {
+		return ErrNotInRoom
+	}
+	o.cleanupMedia(from)
+	before, _ := room.MemberDTO(from)
+	session.Meta().MediaConnected = session.Media() != nil
+	if !room.ReplaceMember(from, sid, session) {
+		return ErrNotInRoom
+	}
+	o.Registry.RemoveRoom(from)
+	o.Registry.UpdateRoom(sid, roomID)
+	if floor, ok := o.Floors.Get(roomID); ok {
+		floor.Replace(from, sid)
+	}
+	o.refreshForwarding(roomID)
+	if after, ok := room.MemberDTO(sid); ok {
+		o.announceMember(room, before, after)
+	}
+	log.Info().Str("module", "orch").Str("from_sid", string(from)).Str("sid", string(sid)).Str("room_id", string(roomID)).Msg("moved to another session")
+	return nil
+}
+
+// refreshUser gives sid's member the current user of its client, which a
+// rename may have replaced while sid was outside any room. Members in a room
+// get theirs from userChanged instead.
+func (o *Orchestrator) refreshUser(sid core.SessionID) {
+	if _, _, ok := o.Registry.RoomOf(sid); ok {
+		return
+	}
+	sess, ok := o.Registry.GetSession(sid)
+	if !ok {
+		return
+	}
+	if user, err := o.Registry.GetOrCreateUser(sid); err == nil {
+		sess.Meta().User = user
+	}
+}
+
+// CheckJoin runs the checks of Join without joining, so a caller can fail
+// before undoing anything on the user's behalf, such as moving them off
+// another device.
+func (o *Orchestrator) CheckJoin(sid core.SessionID, roomID domain.RoomID) error {
+	_, _, err := o.joinable(sid, roomID)
+	return err
+}
+
+// joinable resolves the room and session for a join of sid into roomID.
+// Another session of the same user in the room does not count towards its
+// capacity, since joining replaces it.
+func (o *Orchestrator) joinable(sid core.SessionID, roomID domain.RoomID) (core.RoomService, core.MemberSession, error) {
 	existRoomID, _, ok := o.Registry.RoomOf(sid)
 	if ok {
 		log.Info().Str("sid", string(sid)).Str("roomID", string(existRoomID)).Msg("already in room")
-		return
+		return nil, nil, ErrAlreadyInRoom
 	}
-	if session, ok := o.Registry.GetSession(sid); ok {
-		room, ok := o.Rooms.GetRoom(roomID)
-		if !ok {
-			log.Error().Str("module", "orch").Str("room_id", string(roomID)).Msg("room not exists")
-			return
-		}
-		room.AddMember(sid, session)
-		o.Registry.UpdateRoom(sid, roomID)
-		log.Info().Str("sid", string(sid)).Str("room_id", string(roomID)).Msg("added to room")
+	if o.Registry.IsEcho(sid) {
+		log.Info().Str("sid", string(sid)).Msg("already in echo test")
+		return nil, nil, ErrAlreadyInRoom
+	}
+	session, ok := o.Registry.GetSession(sid)
+	if !ok {
+		return nil, nil, ErrNoSession
+	}
+	room, ok := o.Rooms.GetRoom(roomID)
+	if !ok {
+		log.Error().Str("module", "orch").Str("room_id", string(roomID)).Msg("room not exists")
+		return nil, nil, ErrRoomNotFound
+	}
+	if phase, _ := room.Phase(); phase == domain.RoomClosed {
+		return nil, nil, ErrRoomNotFound
 	}
+	meta := session.Meta()
+	count := room.MemberCount()
+	if other, ok := room.SessionOf(meta.User.ID); ok && other != sid {
+		count--
+	}
+	if max := room.Room().Settings.MaxMembers; max > 0 && count >= max {
+		log.Info().Str("module", "orch").Str("sid", string(sid)).Str("room_id", string(roomID)).Int("max", max).Msg("room full")
+		return nil, nil, ErrRoomFull
+	}
+	if ban, ok := room.BanOf(meta.User.ID, meta.ClientIP, time.Now()); ok {
+		log.Info().Str("module", "orch").Str("sid", string(sid)).Str("room_id", string(roomID)).Msg("banned user rejected")
+		return nil, nil, &BanError{Ban: ban}
+	}
+	return room, session, nil
+}
+
+// JoinEcho puts sid into the loopback echo test. It fails if sid is already in a room.
+func (o *Orchestrator) JoinEcho(sid core.SessionID) error {
+	if _, _, ok := o.Registry.RoomOf(sid); ok {
+		return ErrAlreadyInRoom
+	}
+	if !o.Registry.SetEcho(sid, true) {
+		return ErrNoSession
+	}
+	log.Info().Str("module", "orch").Str("sid", string(sid)).Msg("joined echo test")
+	return nil
 }
 
 func (o *Orchestrator) KickBySID(sid core.SessionID) {
@@ -30,23 +153,78 @@ func (o *Orchestrator) KickBySID(sid core.SessionID) {
 }
 
 func (o *Orchestrator) cleanupMembership(sid core.SessionID) {
+	if o.Registry.IsEcho(sid) {
+		o.Registry.SetEcho(sid, false)
+		return
+	}
 	roomID, _, ok := o.Registry.RoomOf(sid)
 	if !ok {
 		return
 	}
+	o.Registry.RemoveRoom(sid)
 	room, ok := o.Rooms.GetRoom(roomID)
-	if ok {
-		room.RemoveMember(sid)
-		if room.MemberCount() == 0 {
-			o.Rooms.StopRoom(roomID)
+	if !ok {
+		return
+	}
+	if floor, ok := o.Floors.Get(roomID); ok {
+		floor.Leave(sid)
+	}
+	o.leaveWhispers(room, sid)
+	self, _ := room.MemberDTO(sid)
+	room.RemoveMember(sid)
+	switch phase, _ := room.Phase(); {
+	case phase == domain.RoomClosed:
+		// EvictRoom is tearing the room down.
+	case room.MemberCount() == 0 && o.RoomEmptyGrace <= 0 && !room.Room().Settings.Persistent:
+		o.EvictRoom(roomID, core.RoomClosedEmpty)
+	default:
+		// An empty room waits for the janitor; whoever comes back in
+		// the meantime finds it as they left it.
+		if self.RoomRole == domain.RoleOwner && room.MemberCount() > 0 {
+			o.handOverOwnership(room, self.ID)
 		}
+		o.publishRoomList(room, core.RoomListUpdated)
 	}
-	o.Registry.RemoveRoom(sid)
 }
 
-func (o *Orchestrator) EvictRoom(id domain.RoomID) {
+// EvictRoom closes the room: members are told why, removed, and the room
+// is dropped. Closing a room twice is a no-op.
+func (o *Orchestrator) EvictRoom(id domain.RoomID, reason core.CloseReason) {
+	room, ok := o.Rooms.GetRoom(id)
+	if !ok || !room.Close() {
+		return
+	}
+	log.Info().Str("module", "orch").Str("room_id", string(id)).Str("reason", string(reason)).Msg("closing room")
+	o.publish(core.RoomClosed{Room: core.InfoOf(room), Reason: reason})
 	for _, snap := range o.Registry.MembersOfRoom(id) {
 		o.KickBySID(snap.SID)
 	}
+	o.stopRoom(id)
+}
+
+// stopRoom drops the room together with its per-room app state.
+func (o *Orchestrator) stopRoom(id domain.RoomID) {
+	room, ok := o.Rooms.GetRoom(id)
+	o.Floors.Drop(id)
 	o.Rooms.StopRoom(id)
+	if ok {
+		o.publishRoomList(room, core.RoomListClosed)
+	}
+}
+
+// currentRoom resolves the room sid is in together with sid's own member view.
+func (o *Orchestrator) currentRoom(sid core.SessionID) (core.RoomService, core.MemberDTO, error) {
+	roomID, _, ok := o.Registry.RoomOf(sid)
+	if !ok {
+		return nil, core.MemberDTO{}, ErrNotInRoom
+	}
+	room, ok := o.Rooms.GetRoom(roomID)
+	if !ok {
+		return nil, core.MemberDTO{}, ErrNotInRoom
+	}
+	self, ok := room.MemberDTO(sid)
+	if !ok {
+		return nil, core.MemberDTO{}, ErrNotInRoom
+	}
+	return room, self, nil
 }
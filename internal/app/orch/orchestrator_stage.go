@@ -0,0 +1,112 @@
+package orch
+
+import (
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// RaiseHand puts an audience member into the room's hand-raise queue.
+func (o *Orchestrator) RaiseHand(sid core.SessionID) (core.RoomService, error) {
+	room, self, err := o.stageRoom(sid)
+	if err != nil {
+		return nil, err
+	}
+	if self.Role.CanSpeak() {
+		return nil, ErrAlreadySpeaker
+	}
+	o.updateMember(room, sid, func(m *domain.Member) { m.HandRaised = true })
+	return room, nil
+}
+
+// LowerHand removes the member from the hand-raise queue.
+func (o *Orchestrator) LowerHand(sid core.SessionID) (core.RoomService, error) {
+	room, _, err := o.stageRoom(sid)
+	if err != nil {
+		return nil, err
+	}
+	o.updateMember(room, sid, func(m *domain.Member) { m.HandRaised = false })
+	return room, nil
+}
+
+// Promote makes target a speaker. Only moderators may promote. A track
+// the target sent while in the audience starts being forwarded now.
+func (o *Orchestrator) Promote(sid core.SessionID, target domain.UserID) (core.RoomService, core.SessionID, error) {
+	room, targetSID, err := o.setStageRole(sid, target, domain.StageSpeaker)
+	if err != nil {
+		return nil, "", err
+	}
+	if o.Relays != nil {
+		if ctx, track, ok := o.Relays.Unpark(targetSID); ok {
+			o.startPublishing(ctx, targetSID, track)
+			return room, targetSID, nil
+		}
+	}
+	o.refreshForwarding(room.Room().ID)
+	return room, targetSID, nil
+}
+
+// Demote moves target to the audience. Their relay is held muted rather
+// than stopped, so a later Promote resumes it, and a floor they held or
+// queued for is given up.
+func (o *Orchestrator) Demote(sid core.SessionID, target domain.UserID) (core.RoomService, core.SessionID, error) {
+	room, targetSID, err := o.setStageRole(sid, target, domain.StageAudience)
+	if err != nil {
+		return nil, "", err
+	}
+	if room.Room().Settings.FloorControl {
+		o.roomFloor(room).Release(targetSID)
+	}
+	o.refreshForwarding(room.Room().ID)
+	return room, targetSID, nil
+}
+
+func (o *Orchestrator) setStageRole(sid core.SessionID, target domain.UserID, role domain.StageRole) (core.RoomService, core.SessionID, error) {
+	room, self, err := o.stageRoom(sid)
+	if err != nil {
+		return nil, "", err
+	}
+	if !room.IsModerator(self.ID) {
+		return nil, "", ErrNotModerator
+	}
+	targetSID, ok := room.SessionOf(target)
+	if !ok {
+		return nil, "", ErrNoSuchMember
+	}
+	_, ok = o.updateMember(room, targetSID, func(m *domain.Member) {
+		m.StageRole = role
+		if role.CanSpeak() {
+			m.HandRaised = false
+		}
+	})
+	if !ok {
+		return nil, "", ErrNoSuchMember
+	}
+	log.Info().
+		Str("module", "orch").
+		Str("sid", string(sid)).
+		Str("target_sid", string(targetSID)).
+		Str("role", string(role)).
+		Msg("stage role changed")
+	return room, targetSID, nil
+}
+
+// stageRoom resolves the stage-mode room sid is in together with sid's own member view.
+func (o *Orchestrator) stageRoom(sid core.SessionID) (core.RoomService, core.MemberDTO, error) {
+	room, self, err := o.currentRoom(sid)
+	if err != nil {
+		return nil, self, err
+	}
+	if !room.Room().IsStage() {
+		return nil, self, ErrNotStage
+	}
+	return room, self, nil
+}
+
+// initialStageRole picks the role a member gets when joining a stage room.
+func initialStageRole(room core.RoomService, uid domain.UserID) domain.StageRole {
+	if room.IsModerator(uid) {
+		return domain.StageSpeaker
+	}
+	return domain.StageAudience
+}
@@ -0,0 +1,104 @@
+package orch
+
+import (
+	"slices"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+)
+
+// StartWhisper makes sid audible only to the given members until EndWhisper.
+func (o *Orchestrator) StartWhisper(sid core.SessionID, targets []domain.UserID) error {
+	room, self, err := o.currentRoom(sid)
+	if err != nil {
+		return err
+	}
+	if !self.Role.CanSpeak() {
+		return ErrAudience
+	}
+	to := make([]core.SessionID, 0, len(targets))
+	for _, uid := range targets {
+		if targetSID, ok := room.SessionOf(uid); ok && targetSID != sid && !slices.Contains(to, targetSID) {
+			to = append(to, targetSID)
+		}
+	}
+	if len(to) == 0 {
+		return ErrNoTargets
+	}
+
+	// A new whisper replaces the previous one; its old targets are told it ended.
+	prev, replaced := room.EndWhisper(sid)
+	room.StartWhisper(sid, to)
+	o.refreshForwarding(room.Room().ID)
+	if replaced {
+		o.publishWhisper(room, sid, prev, false)
+	}
+	o.publishWhisper(room, sid, to, true)
+	return nil
+}
+
+// EndWhisper makes sid audible to the whole room again.
+func (o *Orchestrator) EndWhisper(sid core.SessionID) error {
+	room, _, err := o.currentRoom(sid)
+	if err != nil {
+		return err
+	}
+	targets, ok := room.EndWhisper(sid)
+	if !ok {
+		return ErrNotWhispering
+	}
+	o.refreshForwarding(room.Room().ID)
+	o.publishWhisper(room, sid, targets, false)
+	return nil
+}
+
+// leaveWhispers settles whispers that sid's departure touches: its own and
+// any whose last target is sid end, the others lose sid as a target. Must
+// run before sid is removed from the room.
+func (o *Orchestrator) leaveWhispers(room core.RoomService, sid core.SessionID) {
+	for from, targets := range room.Whispers() {
+		if from != sid && !slices.Contains(targets, sid) {
+			continue
+		}
+		rest := slices.DeleteFunc(slices.Clone(targets), func(t core.SessionID) bool { return t == sid })
+		if from == sid || len(rest) == 0 {
+			if _, ok := room.EndWhisper(from); ok {
+				o.publishWhisper(room, from, targets, false)
+			}
+			continue
+		}
+		room.StartWhisper(from, rest)
+		o.publishWhisperUpdate(room, from, rest)
+	}
+}
+
+// publishWhisperUpdate tells the speaker and the remaining targets who the
+// whisper reaches now.
+func (o *Orchestrator) publishWhisperUpdate(room core.RoomService, from core.SessionID, targets []core.SessionID) {
+	o.publishWhisperEvent(room, from, targets, true, true)
+}
+
+func (o *Orchestrator) publishWhisper(room core.RoomService, from core.SessionID, targets []core.SessionID, active bool) {
+	o.publishWhisperEvent(room, from, targets, active, false)
+}
+
+func (o *Orchestrator) publishWhisperEvent(room core.RoomService, from core.SessionID, targets []core.SessionID, active, updated bool) {
+	speaker, ok := room.MemberDTO(from)
+	if !ok {
+		return
+	}
+	ev := core.WhisperChanged{
+		Room:    room.Room().ID,
+		From:    speaker,
+		Targets: make([]core.MemberDTO, 0, len(targets)),
+		To:      append([]core.SessionID{from}, targets...),
+		Active:  active,
+		Updated: updated,
+	}
+	for _, sid := range targets {
+		if dto, ok := room.MemberDTO(sid); ok {
+			ev.Targets = append(ev.Targets, dto)
+		}
+	}
+	o.publish(ev)
+}
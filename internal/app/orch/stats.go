@@ -0,0 +1,23 @@
+package orch
+
+import "github.com/dkeye/Voice/internal/app"
+
+// Stats is a point-in-time count of what the server holds in memory.
+type Stats struct {
+	app.RegistryStats
+	Rooms  int `json:"rooms"`
+	Peers  int `json:"peers"`
+	Relays int `json:"relays"`
+}
+
+func (o *Orchestrator) Stats() Stats {
+	st := Stats{
+		RegistryStats: o.Registry.Stats(),
+		Rooms:         len(o.Rooms.List()),
+		Peers:         o.Registry.MediaCount(),
+	}
+	if o.Relays != nil {
+		st.Relays = o.Relays.Count()
+	}
+	return st
+}
@@ -2,7 +2,12 @@ package app
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
 	"sync"
+	"time"
 
 	"github.com/dkeye/Voice/internal/core"
 	"github.com/dkeye/Voice/internal/domain"
@@ -10,62 +15,249 @@ import (
 )
 
 type sessionEntry struct {
+	Client  core.ClientID
 	RoomID  domain.RoomID
 	Session core.MemberSession
 	Cancel  context.CancelFunc
+	// Echo marks a session in the loopback echo test; it has no room.
+	Echo bool
+	// Lobby is set while the session follows public room list changes.
+	Lobby bool
+	// Resume is the token a reconnecting client presents to take the
+	// session over. It rotates on every bind.
+	Resume string
+	// expire is armed while the signal is detached and fires the leave
+	// path once the grace period runs out.
+	expire *time.Timer
+}
+
+// userEntry is the user behind a client cookie.
+type userEntry struct {
+	User    *domain.User
+	Prefs   domain.UserPrefs
+	Created time.Time
+	Roles   []string
+	// Login and hash are set when the cookie is an account.
+	Login string
+	hash  []byte
+	// seen is when a session of the client was last bound or unbound;
+	// users without sessions are evicted once it is old enough.
+	seen time.Time
+	// stored is set once the user has a profile in the Store.
+	stored bool
 }
 
 type Registry struct {
 	mu       sync.RWMutex
 	sessions map[core.SessionID]*sessionEntry
-	users    map[core.SessionID]*domain.User
+	users    map[core.ClientID]*userEntry
+
+	// Store, if set before use, keeps user profiles across restarts; the
+	// users map is then only a cache of the clients seen lately.
+	Store core.UserStore
+	// saveMu orders writes to the Store, which happen outside mu.
+	saveMu sync.Mutex
 }
 
 func NewRegistry() *Registry {
 	return &Registry{
 		sessions: make(map[core.SessionID]*sessionEntry),
-		users:    make(map[core.SessionID]*domain.User),
+		users:    make(map[core.ClientID]*userEntry),
 	}
 }
 
+var (
+	// ErrUnknownSession is returned for a session the registry has never bound.
+	ErrUnknownSession = errors.New("unknown session")
+	ErrUserIDTooLong  = errors.New("user id too long")
+)
+
+// ClientUser returns the user behind cid, creating a guest on first use.
+// All sessions of a client share this user.
+func (r *Registry) ClientUser(cid core.ClientID) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.clientUserLocked(cid)
+}
+
+// GetOrCreateUser returns the user of the client that owns sid.
 func (r *Registry) GetOrCreateUser(sid core.SessionID) (*domain.User, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if u, ok := r.users[sid]; ok {
-		return u, nil
+	e, ok := r.sessions[sid]
+	if !ok {
+		return nil, ErrUnknownSession
+	}
+	return r.clientUserLocked(e.Client)
+}
+
+func (r *Registry) clientUserLocked(cid core.ClientID) (*domain.User, error) {
+	if u, ok := r.users[cid]; ok {
+		u.seen = time.Now()
+		return u.User, nil
+	}
+	if e, ok := r.loadUserLocked(cid); ok {
+		r.users[cid] = e
+		return e.User, nil
 	}
 	u, err := domain.NewUser("guest")
 	if err != nil {
 		return nil, err
 	}
-	r.users[sid] = u
-	log.Info().Str("module", "app.registry").Str("sid", string(sid)).Msg("created new user")
+	// A guest is only written to the Store once there is something of it
+	// worth keeping, such as a name or preferences.
+	e := &userEntry{User: u, Created: time.Now().UTC(), seen: time.Now()}
+	r.users[cid] = e
+	log.Info().Str("module", "app.registry").Str("client", string(cid)).Msg("created new user")
 	return u, nil
 }
 
 func (r *Registry) UpdateUsername(sid core.SessionID, name string) error {
 	r.mu.Lock()
-	defer r.mu.Unlock()
-	if u, ok := r.users[sid]; ok {
-		err := u.SetUsername(name)
-		if err != nil {
-			log.Error().Err(err).Str("module", "app.registry").Str("sid", string(sid)).Msg("failed to update username")
-			return err
-		}
-		log.Info().
-			Str("module", "app.registry").
-			Str("sid", string(sid)).
-			Str("name", name).
-			Msg("username updated")
+	e, ok := r.sessions[sid]
+	if !ok {
+		r.mu.Unlock()
+		return ErrUnknownSession
+	}
+	u, ok := r.users[e.Client]
+	if !ok {
+		r.mu.Unlock()
+		return nil
+	}
+	// Users are handed out to sessions and rooms, so a rename makes a new one.
+	renamed := *u.User
+	if err := renamed.SetUsername(name); err != nil {
+		r.mu.Unlock()
+		log.Error().Err(err).Str("module", "app.registry").Str("sid", string(sid)).Msg("failed to update username")
+		return err
 	}
+	u.User = &renamed
+	r.mu.Unlock()
+	r.persist(e.Client)
+	log.Info().
+		Str("module", "app.registry").
+		Str("sid", string(sid)).
+		Str("name", name).
+		Msg("username updated")
 	return nil
 }
 
-func (r *Registry) BindSignal(sid core.SessionID, sess core.MemberSession, cancel context.CancelFunc) {
+// SessionsOf lists the live sessions of cid, one per device or tab.
+func (r *Registry) SessionsOf(cid core.ClientID) []core.SessionID {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []core.SessionID
+	for sid, e := range r.sessions {
+		if e.Client == cid {
+			out = append(out, sid)
+		}
+	}
+	return out
+}
+
+// BindSignal registers a new session sid for client cid and returns the
+// resume token issued for it. Other sessions of the client are untouched.
+// With max > 0 it refuses, under the same lock, once max sessions are bound.
+func (r *Registry) BindSignal(cid core.ClientID, sid core.SessionID, sess core.MemberSession, cancel context.CancelFunc, max int) (string, bool) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.sessions[sid] = &sessionEntry{Session: sess, Cancel: cancel}
-	log.Info().Str("module", "app.registry").Str("sid", string(sid)).Msg("bound signal")
+	if _, ok := r.sessions[sid]; !ok && max > 0 && len(r.sessions) >= max {
+		return "", false
+	}
+	if e, ok := r.sessions[sid]; ok && e.expire != nil {
+		e.expire.Stop()
+	}
+	token := newResumeToken()
+	r.sessions[sid] = &sessionEntry{Client: cid, Session: sess, Cancel: cancel, Resume: token}
+	log.Info().Str("module", "app.registry").Str("client", string(cid)).Str("sid", string(sid)).Msg("bound signal")
+	return token, true
+}
+
+// Resume reattaches conn to the session of cid that was issued token, keeping
+// room membership and media untouched. The previous signal, if any, is
+// returned so the caller can close it. The resume token is rotated.
+func (r *Registry) Resume(cid core.ClientID, token string, conn core.SignalConnection, cancel context.CancelFunc) (sid core.SessionID, prev core.SignalConnection, next string, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if token == "" {
+		return "", nil, "", false
+	}
+	var e *sessionEntry
+	for id, cand := range r.sessions {
+		if cand.Client == cid && subtle.ConstantTimeCompare([]byte(cand.Resume), []byte(token)) == 1 {
+			sid, e = id, cand
+			break
+		}
+	}
+	if e == nil {
+		return "", nil, "", false
+	}
+	if e.expire != nil {
+		e.expire.Stop()
+		e.expire = nil
+	}
+	if e.Cancel != nil {
+		e.Cancel()
+	}
+	prev = e.Session.Signal()
+	e.Session.UpdateSignal(conn)
+	e.Cancel = cancel
+	e.Resume = newResumeToken()
+	log.Info().Str("module", "app.registry").Str("sid", string(sid)).Str("room", string(e.RoomID)).Msg("resumed session")
+	return sid, prev, e.Resume, true
+}
+
+// Detach records that conn has dropped. If conn is still sid's current
+// signal, expire runs after grace unless the session is resumed first;
+// a non-positive grace runs it right away. It reports false when conn was
+// already superseded by another connection.
+func (r *Registry) Detach(sid core.SessionID, conn core.SignalConnection, grace time.Duration, expire func()) bool {
+	r.mu.Lock()
+	e, ok := r.sessions[sid]
+	if !ok || e.Session.Signal() != conn {
+		r.mu.Unlock()
+		return false
+	}
+	if grace <= 0 {
+		r.mu.Unlock()
+		expire()
+		return true
+	}
+	var t *time.Timer
+	t = time.AfterFunc(grace, func() {
+		r.mu.Lock()
+		cur, ok := r.sessions[sid]
+		fire := ok && cur == e && e.expire == t
+		if fire {
+			e.expire = nil
+		}
+		r.mu.Unlock()
+		if fire {
+			log.Info().Str("module", "app.registry").Str("sid", string(sid)).Msg("resume grace expired")
+			expire()
+		}
+	})
+	e.expire = t
+	r.mu.Unlock()
+	log.Info().Str("module", "app.registry").Str("sid", string(sid)).Dur("grace", grace).Msg("detached signal")
+	return true
+}
+
+func newResumeToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ClientOf returns the client that owns sid.
+func (r *Registry) ClientOf(sid core.SessionID) (core.ClientID, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.sessions[sid]
+	if !ok {
+		return "", false
+	}
+	return e.Client, true
 }
 
 func (r *Registry) GetSession(sid core.SessionID) (core.MemberSession, bool) {
@@ -77,6 +269,26 @@ func (r *Registry) GetSession(sid core.SessionID) (core.MemberSession, bool) {
 	return nil, false
 }
 
+// SessionCount returns the number of bound sessions, detached ones included.
+func (r *Registry) SessionCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.sessions)
+}
+
+// MediaCount returns the number of sessions holding a media connection.
+func (r *Registry) MediaCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	n := 0
+	for _, e := range r.sessions {
+		if e.Session.Media() != nil {
+			n++
+		}
+	}
+	return n
+}
+
 func (r *Registry) Unbind(sid core.SessionID) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -84,6 +296,12 @@ func (r *Registry) Unbind(sid core.SessionID) {
 		if e.Cancel != nil {
 			e.Cancel()
 		}
+		if e.expire != nil {
+			e.expire.Stop()
+		}
+		if u, ok := r.users[e.Client]; ok {
+			u.seen = time.Now()
+		}
 		delete(r.sessions, sid)
 	}
 	log.Info().Str("module", "app.registry").Str("sid", string(sid)).Msg("unbind session")
@@ -120,6 +338,51 @@ func (r *Registry) RemoveRoom(sid core.SessionID) {
 	log.Info().Str("module", "app.registry").Str("sid", string(sid)).Msg("removed room association")
 }
 
+func (r *Registry) SetEcho(sid core.SessionID, echo bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.sessions[sid]
+	if !ok {
+		return false
+	}
+	entry.Echo = echo
+	log.Info().Str("module", "app.registry").Str("sid", string(sid)).Bool("echo", echo).Msg("updated echo")
+	return true
+}
+
+func (r *Registry) IsEcho(sid core.SessionID) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := r.sessions[sid]
+	return ok && entry.Echo
+}
+
+// SetLobby subscribes sid to public room list changes or unsubscribes it.
+func (r *Registry) SetLobby(sid core.SessionID, on bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.sessions[sid]
+	if !ok {
+		return false
+	}
+	entry.Lobby = on
+	log.Info().Str("module", "app.registry").Str("sid", string(sid)).Bool("lobby", on).Msg("updated lobby subscription")
+	return true
+}
+
+// LobbySessions returns the sessions subscribed to room list changes.
+func (r *Registry) LobbySessions() []regSnap {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []regSnap
+	for sid, e := range r.sessions {
+		if e.Lobby {
+			out = append(out, regSnap{SID: sid, Session: e.Session})
+		}
+	}
+	return out
+}
+
 type regSnap struct {
 	SID     core.SessionID
 	Session core.MemberSession
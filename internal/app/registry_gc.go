@@ -0,0 +1,116 @@
+package app
+
+import (
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// RegistryStats counts what the registry holds.
+type RegistryStats struct {
+	Sessions int `json:"sessions"`
+	// Detached sessions are waiting out their resume grace.
+	Detached int `json:"detached"`
+	Users    int `json:"users"`
+}
+
+func (r *Registry) Stats() RegistryStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	st := RegistryStats{Sessions: len(r.sessions), Users: len(r.users)}
+	for _, e := range r.sessions {
+		if e.expire != nil {
+			st.Detached++
+		}
+	}
+	return st
+}
+
+// StaleSessions lists sessions whose signal connection is gone without a
+// resume grace pending: nothing will ever come back for them.
+func (r *Registry) StaleSessions() []core.SessionID {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []core.SessionID
+	for sid, e := range r.sessions {
+		if e.expire != nil {
+			continue
+		}
+		if sig := e.Session.Signal(); sig == nil || sig.IsClosed() {
+			out = append(out, sid)
+		}
+	}
+	return out
+}
+
+// ReapUsers forgets users that have had no session for ttl and returns how
+// many went. A client coming back after that is loaded from the Store, or
+// starts as a new guest when there is none. Stored users get their last
+// seen time written back so that ExpireGuests judges them by it.
+func (r *Registry) ReapUsers(now time.Time, ttl time.Duration) int {
+	r.mu.Lock()
+	active := make(map[core.ClientID]struct{}, len(r.sessions))
+	for _, e := range r.sessions {
+		active[e.Client] = struct{}{}
+	}
+	n := 0
+	seen := make(map[core.ClientID]domain.UserProfile)
+	for cid, u := range r.users {
+		if _, ok := active[cid]; ok || now.Sub(u.seen) < ttl {
+			continue
+		}
+		delete(r.users, cid)
+		n++
+		if u.stored && r.Store != nil {
+			seen[cid] = u.profile()
+		}
+	}
+	r.mu.Unlock()
+
+	if len(seen) > 0 {
+		r.saveMu.Lock()
+		for cid, p := range seen {
+			r.mu.RLock()
+			_, back := r.users[cid]
+			r.mu.RUnlock()
+			if back {
+				continue
+			}
+			if err := r.Store.SaveUser(string(cid), p); err != nil {
+				log.Error().Err(err).Str("module", "app.registry").Str("client", string(cid)).Msg("save user")
+			}
+		}
+		r.saveMu.Unlock()
+	}
+	if n > 0 {
+		log.Info().Str("module", "app.registry").Int("users", n).Msg("reaped idle users")
+	}
+	return n
+}
+
+// ExpireGuests deletes stored guest profiles not seen for ttl and returns
+// how many went. Users in the cache are in use and kept.
+func (r *Registry) ExpireGuests(now time.Time, ttl time.Duration) int {
+	if r.Store == nil {
+		return 0
+	}
+	r.mu.RLock()
+	cached := make(map[string]struct{}, len(r.users))
+	for cid := range r.users {
+		cached[string(cid)] = struct{}{}
+	}
+	r.mu.RUnlock()
+	n, err := r.Store.ExpireGuests(now.Add(-ttl), func(key string) bool {
+		_, ok := cached[key]
+		return ok
+	})
+	if err != nil {
+		log.Error().Err(err).Str("module", "app.registry").Msg("expire guests")
+	}
+	if n > 0 {
+		log.Info().Str("module", "app.registry").Int("users", n).Msg("expired stale guests")
+	}
+	return n
+}
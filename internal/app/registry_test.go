@@ -0,0 +1,203 @@
+package app
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+)
+
+// fakeConn is a core.SignalConnection that drops every frame.
+type fakeConn struct {
+	closed atomic.Bool
+}
+
+func (c *fakeConn) TrySend(core.Frame) error { return nil }
+func (c *fakeConn) Close()                   { c.closed.Store(true) }
+func (c *fakeConn) IsClosed() bool           { return c.closed.Load() }
+
+// bindTestSession binds sid for cid over conn and returns its resume token.
+func bindTestSession(t *testing.T, r *Registry, cid core.ClientID, sid core.SessionID, conn core.SignalConnection) string {
+	t.Helper()
+	u, err := r.ClientUser(cid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess := core.NewMemberSession(domain.NewMember(u)).UpdateSignal(conn)
+	token, ok := r.BindSignal(cid, sid, sess, func() {}, 0)
+	if !ok || token == "" {
+		t.Fatalf("BindSignal(%q) = %q, %v", sid, token, ok)
+	}
+	return token
+}
+
+func TestRegistryResumeRotatesToken(t *testing.T) {
+	r := NewRegistry()
+	old := &fakeConn{}
+	token := bindTestSession(t, r, "client", "s1", old)
+
+	var cancelled atomic.Bool
+	conn := &fakeConn{}
+	sid, prev, next, ok := r.Resume("client", token, conn, func() { cancelled.Store(true) })
+	if !ok || sid != "s1" {
+		t.Fatalf("Resume = %q, %v; want s1", sid, ok)
+	}
+	if prev != old {
+		t.Fatal("Resume did not hand back the previous signal")
+	}
+	if next == "" || next == token {
+		t.Fatalf("resume token was not rotated: %q", next)
+	}
+	if sess, _ := r.GetSession("s1"); sess.Signal() != conn {
+		t.Fatal("session still uses the old signal")
+	}
+
+	if _, _, _, ok := r.Resume("client", token, &fakeConn{}, nil); ok {
+		t.Fatal("a spent resume token was accepted again")
+	}
+	if _, _, _, ok := r.Resume("client", next, &fakeConn{}, nil); !ok {
+		t.Fatal("the rotated resume token was refused")
+	}
+	if !cancelled.Load() {
+		t.Fatal("the superseded signal was not cancelled")
+	}
+}
+
+func TestRegistryResumeRejects(t *testing.T) {
+	r := NewRegistry()
+	token := bindTestSession(t, r, "client", "s1", &fakeConn{})
+
+	tests := []struct {
+		name  string
+		cid   core.ClientID
+		token string
+	}{
+		{"empty token", "client", ""},
+		{"wrong token", "client", "0123456789abcdef0123456789abcdef"},
+		{"other client", "intruder", token},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, _, ok := r.Resume(tt.cid, tt.token, &fakeConn{}, nil); ok {
+				t.Fatal("Resume succeeded")
+			}
+		})
+	}
+}
+
+func TestRegistryDetachSupersededConn(t *testing.T) {
+	r := NewRegistry()
+	old := &fakeConn{}
+	token := bindTestSession(t, r, "client", "s1", old)
+	if _, _, _, ok := r.Resume("client", token, &fakeConn{}, nil); !ok {
+		t.Fatal("Resume failed")
+	}
+
+	if r.Detach("s1", old, 0, func() { t.Error("expire ran for a superseded signal") }) {
+		t.Fatal("Detach of a superseded signal reported true")
+	}
+	if r.Detach("missing", old, 0, func() { t.Error("expire ran for an unknown session") }) {
+		t.Fatal("Detach of an unknown session reported true")
+	}
+}
+
+func TestRegistryDetachWithoutGraceExpiresAtOnce(t *testing.T) {
+	r := NewRegistry()
+	conn := &fakeConn{}
+	bindTestSession(t, r, "client", "s1", conn)
+
+	fired := false
+	if !r.Detach("s1", conn, 0, func() { fired = true }) {
+		t.Fatal("Detach reported false")
+	}
+	if !fired {
+		t.Fatal("expire did not run with no grace")
+	}
+}
+
+func TestRegistryDetachExpiresAfterGrace(t *testing.T) {
+	r := NewRegistry()
+	conn := &fakeConn{}
+	bindTestSession(t, r, "client", "s1", conn)
+
+	fired := make(chan struct{})
+	if !r.Detach("s1", conn, 10*time.Millisecond, func() { close(fired) }) {
+		t.Fatal("Detach reported false")
+	}
+	select {
+	case <-fired:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expire never ran")
+	}
+}
+
+func TestRegistryResumeWithinGraceStopsExpiry(t *testing.T) {
+	r := NewRegistry()
+	conn := &fakeConn{}
+	token := bindTestSession(t, r, "client", "s1", conn)
+
+	var fired atomic.Bool
+	if !r.Detach("s1", conn, 30*time.Millisecond, func() { fired.Store(true) }) {
+		t.Fatal("Detach reported false")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, _, _, ok := r.Resume("client", token, &fakeConn{}, cancel); !ok {
+		t.Fatal("Resume within grace failed")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if fired.Load() {
+		t.Fatal("expire ran although the session was resumed")
+	}
+	if ctx.Err() != nil {
+		t.Fatal("the resumed signal was cancelled")
+	}
+	if _, ok := r.GetSession("s1"); !ok {
+		t.Fatal("resumed session is gone")
+	}
+}
+
+func TestRegistryRenameReplacesUser(t *testing.T) {
+	r := NewRegistry()
+	bindTestSession(t, r, "client", "s1", &fakeConn{})
+	before, _ := r.ClientUser("client")
+	old := before.Username
+
+	if err := r.UpdateUsername("s1", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := r.ClientUser("client")
+	if after.Username != "alice" || after.ID != before.ID {
+		t.Fatalf("user after rename = %+v", after)
+	}
+	if before.Username != old {
+		t.Fatal("rename wrote into the user sessions already hold")
+	}
+}
+
+func TestRegistryIdentifyReplacesUser(t *testing.T) {
+	r := NewRegistry()
+	first, err := r.Identify(core.Identity{Client: "client", User: "u1", Name: "Alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	same, _ := r.Identify(core.Identity{Client: "client", User: "u1", Name: "Alice"})
+	if same != first {
+		t.Fatal("an unchanged identity made a new user")
+	}
+
+	second, err := r.Identify(core.Identity{Client: "client", User: "u1", Name: "Alicia", Roles: []string{"admin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Username != "Alicia" || first.Username != "Alice" {
+		t.Fatalf("identify changed %+v in place or missed the new name %+v", first, second)
+	}
+	if cur, _ := r.ClientUser("client"); cur != second {
+		t.Fatal("ClientUser does not return the new user")
+	}
+}
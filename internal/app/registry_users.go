@@ -0,0 +1,150 @@
+package app
+
+import (
+	"slices"
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// Profile returns the full profile of the user behind sid.
+func (r *Registry) Profile(sid core.SessionID) (domain.UserProfile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.sessions[sid]
+	if !ok {
+		return domain.UserProfile{}, ErrUnknownSession
+	}
+	if _, err := r.clientUserLocked(e.Client); err != nil {
+		return domain.UserProfile{}, err
+	}
+	return r.users[e.Client].profile(), nil
+}
+
+// UpdatePrefs replaces the preferences of the user behind sid.
+func (r *Registry) UpdatePrefs(sid core.SessionID, prefs domain.UserPrefs) error {
+	r.mu.Lock()
+	e, ok := r.sessions[sid]
+	if !ok {
+		r.mu.Unlock()
+		return ErrUnknownSession
+	}
+	if _, err := r.clientUserLocked(e.Client); err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	u := r.users[e.Client]
+	changed := u.Prefs != prefs
+	u.Prefs = prefs
+	r.mu.Unlock()
+	if changed {
+		r.persist(e.Client)
+	}
+	return nil
+}
+
+// Identify makes the registry agree with an externally issued identity: the
+// user behind id.Client gets id.User as its ID, and id.Name and id.Roles
+// replace what was known. Identities without a User are left to ClientUser.
+// A change replaces the user rather than writing into the one sessions hold.
+func (r *Registry) Identify(id core.Identity) (*domain.User, error) {
+	if id.User == "" {
+		return r.ClientUser(id.Client)
+	}
+	if len(id.User) > domain.MaxUserIDLen {
+		return nil, ErrUserIDTooLong
+	}
+	name := id.Name
+	if name == "" {
+		name = string(id.User)
+	}
+	if len(name) > domain.MaxUsernameLen {
+		name = name[:domain.MaxUsernameLen]
+	}
+	r.mu.Lock()
+	e, ok := r.users[id.Client]
+	if !ok {
+		if e, ok = r.loadUserLocked(id.Client); !ok {
+			e = &userEntry{User: &domain.User{}, Created: time.Now().UTC()}
+		}
+		r.users[id.Client] = e
+	}
+	e.seen = time.Now()
+	if e.User.ID == id.User && e.User.Username == name && slices.Equal(e.Roles, id.Roles) {
+		r.mu.Unlock()
+		return e.User, nil
+	}
+	user := &domain.User{ID: id.User, Username: name}
+	e.User, e.Roles = user, id.Roles
+	r.mu.Unlock()
+	r.persist(id.Client)
+	log.Info().Str("module", "app.registry").Str("client", string(id.Client)).Str("user_id", string(id.User)).Strs("roles", id.Roles).Msg("identity updated")
+	return user, nil
+}
+
+func (u *userEntry) profile() domain.UserProfile {
+	return domain.UserProfile{
+		ID:           u.User.ID,
+		Username:     u.User.Username,
+		Prefs:        u.Prefs,
+		CreatedAt:    u.Created,
+		SeenAt:       u.seen.UTC(),
+		Roles:        u.Roles,
+		Login:        u.Login,
+		PasswordHash: u.hash,
+	}
+}
+
+// loadUserLocked brings a stored profile back into the cache.
+func (r *Registry) loadUserLocked(cid core.ClientID) (*userEntry, bool) {
+	if r.Store == nil {
+		return nil, false
+	}
+	p, ok, err := r.Store.LoadUser(string(cid))
+	if err != nil {
+		log.Error().Err(err).Str("module", "app.registry").Str("client", string(cid)).Msg("load user")
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	return &userEntry{
+		User:    &domain.User{ID: p.ID, Username: p.Username},
+		Prefs:   p.Prefs,
+		Created: p.CreatedAt,
+		Roles:   p.Roles,
+		Login:   p.Login,
+		hash:    p.PasswordHash,
+		seen:    time.Now(),
+		stored:  true,
+	}, true
+}
+
+// persist writes the cached profile of cid to the store. Callers must not
+// hold mu: the write happens outside it, and saveMu keeps writes in order.
+// The profile is read afresh under saveMu, so a late writer never replaces
+// a newer profile with an older one. A failed write is logged; the
+// in-memory user stays usable.
+func (r *Registry) persist(cid core.ClientID) {
+	if r.Store == nil {
+		return
+	}
+	r.saveMu.Lock()
+	defer r.saveMu.Unlock()
+	r.mu.Lock()
+	u, ok := r.users[cid]
+	var p domain.UserProfile
+	if ok {
+		u.stored = true
+		p = u.profile()
+	}
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+	if err := r.Store.SaveUser(string(cid), p); err != nil {
+		log.Error().Err(err).Str("module", "app.registry").Str("client", string(cid)).Msg("save user")
+	}
+}
@@ -11,18 +11,41 @@ import (
 type RoomManagerImpl struct {
 	mu    sync.RWMutex
 	rooms map[domain.RoomID]core.RoomService
+	// store keeps persistent rooms; nil keeps everything in memory.
+	store core.RoomStore
 }
 
 func NewRoomManager() core.RoomManager {
 	return &RoomManagerImpl{rooms: make(map[domain.RoomID]core.RoomService)}
 }
 
-func (f *RoomManagerImpl) CreateRoom(name domain.RoomName) core.RoomService {
-	room := core.NewRoomService(domain.RoomName(name))
+// NewPersistentRoomManager saves persistent rooms to store and loads the
+// ones saved before. Members are never stored; restored rooms start empty.
+func NewPersistentRoomManager(store core.RoomStore) (core.RoomManager, error) {
+	recs, err := store.LoadRooms()
+	if err != nil {
+		return nil, err
+	}
+	f := &RoomManagerImpl{rooms: make(map[domain.RoomID]core.RoomService), store: store}
+	for _, rec := range recs {
+		room := core.RestoreRoomService(rec)
+		room.OnRecordChange(f.save)
+		f.rooms[rec.ID] = room
+	}
+	log.Info().Str("module", "app.roommgr").Int("rooms", len(recs)).Msg("restored persistent rooms")
+	return f, nil
+}
+
+func (f *RoomManagerImpl) CreateRoom(name domain.RoomName, settings domain.RoomSettings) core.RoomService {
+	room := core.NewRoomService(name, settings)
 	f.mu.Lock()
-	defer f.mu.Unlock()
 	f.rooms[room.Room().ID] = room
-	log.Info().Str("module", "app.roommgr").Str("room_id", string(room.Room().ID)).Str("room_name", string(room.Room().Name)).Msg("created room")
+	f.mu.Unlock()
+	log.Info().Str("module", "app.roommgr").Str("room_id", string(room.Room().ID)).Str("room_name", string(room.Room().Name)).Str("mode", string(room.Room().Settings.Mode)).Str("visibility", string(room.Room().Settings.Visibility)).Msg("created room")
+	if f.store != nil && settings.Persistent {
+		room.OnRecordChange(f.save)
+		f.save(room.Record())
+	}
 	return room
 }
 
@@ -37,15 +60,28 @@ func (f *RoomManagerImpl) List() []core.RoomInfo {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 	out := make([]core.RoomInfo, 0, len(f.rooms))
-	for id, r := range f.rooms {
-		out = append(out, core.RoomInfo{ID: id, Name: r.Room().Name, MemberCount: r.MemberCount()})
+	for _, r := range f.rooms {
+		out = append(out, core.InfoOf(r))
 	}
 	return out
 }
 
+// StopRoom drops the room; a persistent room is deleted from the store too.
 func (f *RoomManagerImpl) StopRoom(id domain.RoomID) {
 	f.mu.Lock()
-	defer f.mu.Unlock()
+	room, ok := f.rooms[id]
 	delete(f.rooms, id)
+	f.mu.Unlock()
 	log.Info().Str("module", "app.roommgr").Str("room_id", string(id)).Msg("stopped room")
+	if ok && f.store != nil && room.Room().Settings.Persistent {
+		if err := f.store.DeleteRoom(id); err != nil {
+			log.Error().Err(err).Str("module", "app.roommgr").Str("room_id", string(id)).Msg("delete stored room")
+		}
+	}
+}
+
+func (f *RoomManagerImpl) save(rec core.RoomRecord) {
+	if err := f.store.SaveRoom(rec); err != nil {
+		log.Error().Err(err).Str("module", "app.roommgr").Str("room_id", string(rec.ID)).Msg("save room")
+	}
 }
@@ -1,8 +1,11 @@
 package sfu
 
 import (
+	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/pion/rtp"
 	"github.com/pion/webrtc/v4"
 )
 
@@ -14,18 +17,69 @@ const (
 	TrackStateDelete
 )
 
+// MuteReason identifies why an OutTrack is held muted. Reasons combine:
+// the track forwards again only when every reason is cleared.
+type MuteReason uint32
+
+const (
+	// MuteFloor holds a speaker who does not have the push-to-talk floor.
+	MuteFloor MuteReason = 1 << iota
+	// MuteDeafen holds every track destined for a deafened subscriber.
+	MuteDeafen
+	// MuteWhisper holds listeners left out of a speaker's whisper.
+	MuteWhisper
+	// MuteModerator holds a speaker muted by a room moderator.
+	MuteModerator
+	// MuteStage holds a stage-room member who was moved to the audience.
+	MuteStage
+)
+
+// delayQueueSize bounds the packets buffered by a delayed OutTrack.
+const delayQueueSize = 512
+
 // OutTrack represents a single outgoing track to a subscriber.
 type OutTrack struct {
 	Track *webrtc.TrackLocalStaticRTP
-	state atomic.Int32 // Zero by default (TrackStateOk)
+	state atomic.Int32  // Zero by default (TrackStateOk)
+	muted atomic.Uint32 // MuteReason bits
+
+	// delay is nil for direct forwarding.
+	delay *delayLine
 }
 
 func NewOutTrack(track *webrtc.TrackLocalStaticRTP) *OutTrack {
 	return &OutTrack{Track: track}
 }
 
+// NewDelayedOutTrack returns an OutTrack that writes each packet after the given delay.
+func NewDelayedOutTrack(track *webrtc.TrackLocalStaticRTP, delay time.Duration) *OutTrack {
+	ot := &OutTrack{Track: track}
+	if delay > 0 {
+		ot.delay = &delayLine{
+			delay: delay,
+			queue: make(chan delayedPacket, delayQueueSize),
+			done:  make(chan struct{}),
+		}
+		go ot.delay.run(ot)
+	}
+	return ot
+}
+
 func (ot *OutTrack) GetState() TrackState {
-	return TrackState(ot.state.Load())
+	state := TrackState(ot.state.Load())
+	if state == TrackStateOk && ot.muted.Load() != 0 {
+		return TrackStateMuted
+	}
+	return state
+}
+
+// SetMuted sets or clears a single mute reason.
+func (ot *OutTrack) SetMuted(reason MuteReason, muted bool) {
+	if muted {
+		ot.muted.Or(uint32(reason))
+	} else {
+		ot.muted.And(^uint32(reason))
+	}
 }
 
 func (ot *OutTrack) MarkOk() {
@@ -38,4 +92,66 @@ func (ot *OutTrack) MarkMuted() {
 
 func (ot *OutTrack) MarkDelete() {
 	ot.state.Store(int32(TrackStateDelete))
+	if ot.delay != nil {
+		ot.delay.stop()
+	}
+}
+
+// WriteRTP forwards pkt to the subscriber, directly or through the delay line.
+func (ot *OutTrack) WriteRTP(pkt *rtp.Packet) error {
+	if ot.delay != nil {
+		ot.delay.push(pkt)
+		return nil
+	}
+	return ot.Track.WriteRTP(pkt)
+}
+
+type delayedPacket struct {
+	at  time.Time
+	pkt *rtp.Packet
+}
+
+type delayLine struct {
+	delay time.Duration
+	queue chan delayedPacket
+	done  chan struct{}
+	once  sync.Once
+}
+
+func (d *delayLine) push(pkt *rtp.Packet) {
+	select {
+	case d.queue <- delayedPacket{at: time.Now().Add(d.delay), pkt: pkt.Clone()}:
+	default:
+		// Queue is full: drop rather than stall the relay loop.
+	}
+}
+
+func (d *delayLine) stop() {
+	d.once.Do(func() { close(d.done) })
+}
+
+func (d *delayLine) run(ot *OutTrack) {
+	for {
+		select {
+		case <-d.done:
+			return
+		case dp := <-d.queue:
+			if wait := time.Until(dp.at); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-d.done:
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+			}
+			if ot.GetState() != TrackStateOk {
+				continue
+			}
+			if err := ot.Track.WriteRTP(dp.pkt); err != nil {
+				ot.MarkDelete()
+				return
+			}
+		}
+	}
 }
@@ -61,7 +61,7 @@ func (r *Relay) forward(pkt *rtp.Packet, logger *zerolog.Logger) {
 			dirty = append(dirty, dstSID)
 		case TrackStateMuted:
 		case TrackStateOk:
-			if err := ot.Track.WriteRTP(pkt); err != nil {
+			if err := ot.WriteRTP(pkt); err != nil {
 				logger.Error().
 					Err(err).
 					Str("dst_sid", string(dstSID)).
@@ -99,3 +99,21 @@ func (r *Relay) AddOutTrack(dst core.SessionID, ot *OutTrack) {
 	defer r.mu.Unlock()
 	r.outTracks[dst] = ot
 }
+
+// SetMuted applies a mute reason to the OutTrack of dst.
+func (r *Relay) SetMuted(dst core.SessionID, reason MuteReason, muted bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if ot, ok := r.outTracks[dst]; ok {
+		ot.SetMuted(reason, muted)
+	}
+}
+
+// SetAllMuted applies a mute reason to every OutTrack of the relay.
+func (r *Relay) SetAllMuted(reason MuteReason, muted bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, ot := range r.outTracks {
+		ot.SetMuted(reason, muted)
+	}
+}
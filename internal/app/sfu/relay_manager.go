@@ -3,6 +3,7 @@ package sfu
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/dkeye/Voice/internal/core"
 	"github.com/pion/webrtc/v4"
@@ -12,12 +13,42 @@ import (
 type RelayManager struct {
 	mu     sync.RWMutex
 	relays map[core.SessionID]*Relay
+	parked map[core.SessionID]parkedSource
+}
+
+// parkedSource is an upstream track that arrived while its sender was not
+// allowed to speak. pion reports a remote track once, so it is kept to
+// start a relay from later.
+type parkedSource struct {
+	ctx   context.Context
+	track *webrtc.TrackRemote
 }
 
 func NewRelayManager() *RelayManager {
 	return &RelayManager{
 		relays: make(map[core.SessionID]*Relay),
+		parked: make(map[core.SessionID]parkedSource),
+	}
+}
+
+// Park keeps track of sid without forwarding it. ctx is the lifetime of
+// the track's connection; a relay started from it later derives from ctx.
+func (m *RelayManager) Park(ctx context.Context, sid core.SessionID, track *webrtc.TrackRemote) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parked[sid] = parkedSource{ctx: ctx, track: track}
+}
+
+// Unpark takes the track parked for sid, if its connection is still alive.
+func (m *RelayManager) Unpark(sid core.SessionID) (context.Context, *webrtc.TrackRemote, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.parked[sid]
+	delete(m.parked, sid)
+	if !ok || p.ctx.Err() != nil {
+		return nil, nil, false
 	}
+	return p.ctx, p.track, true
 }
 
 // StartRelay creates a new Relay for the given speaker SID and starts its loop.
@@ -47,18 +78,32 @@ func (m *RelayManager) StartRelay(ctx context.Context, sid core.SessionID, track
 }
 
 // AddSubscriber attaches an OutTrack to the relay of srcSID for dstSID.
-func (m *RelayManager) AddSubscriber(srcSID, dstSID core.SessionID, localTrack *webrtc.TrackLocalStaticRTP) {
+// A positive delay holds every packet back by that duration. The OutTrack
+// starts with the muted reasons already set, so nothing slips through
+// before the caller gets to apply them.
+func (m *RelayManager) AddSubscriber(srcSID, dstSID core.SessionID, localTrack *webrtc.TrackLocalStaticRTP, delay time.Duration, muted MuteReason) {
 	m.mu.RLock()
 	relay, ok := m.relays[srcSID]
 	m.mu.RUnlock()
 	if !ok {
 		return
 	}
-	ot := NewOutTrack(localTrack)
+	ot := NewDelayedOutTrack(localTrack, delay)
+	ot.muted.Store(uint32(muted))
 	relay.AddOutTrack(dstSID, ot)
 }
 
-func (m *RelayManager) Subscribe(srcSID, dstSID core.SessionID, pc core.MediaConnection, srcTrack *webrtc.TrackRemote) error {
+// Subscribe forwards the relay of srcSID to dstSID, held by the muted reasons.
+func (m *RelayManager) Subscribe(srcSID, dstSID core.SessionID, pc core.MediaConnection, srcTrack *webrtc.TrackRemote, muted MuteReason) error {
+	return m.subscribe(srcSID, dstSID, pc, srcTrack, 0, muted)
+}
+
+// SubscribeDelayed is Subscribe with packets held back by delay; used for loopback echo.
+func (m *RelayManager) SubscribeDelayed(srcSID, dstSID core.SessionID, pc core.MediaConnection, srcTrack *webrtc.TrackRemote, delay time.Duration) error {
+	return m.subscribe(srcSID, dstSID, pc, srcTrack, delay, 0)
+}
+
+func (m *RelayManager) subscribe(srcSID, dstSID core.SessionID, pc core.MediaConnection, srcTrack *webrtc.TrackRemote, delay time.Duration, muted MuteReason) error {
 	localTrack, err := webrtc.NewTrackLocalStaticRTP(
 		srcTrack.Codec().RTPCodecCapability,
 		srcTrack.ID(),
@@ -84,7 +129,7 @@ func (m *RelayManager) Subscribe(srcSID, dstSID core.SessionID, pc core.MediaCon
 		return err
 	}
 
-	m.AddSubscriber(srcSID, dstSID, localTrack)
+	m.AddSubscriber(srcSID, dstSID, localTrack, delay, muted)
 	log.Info().
 		Str("module", "sfu").
 		Str("src_sid", string(srcSID)).
@@ -111,13 +156,37 @@ func (m *RelayManager) MarkSubscriberDelete(srcSID, dstSID core.SessionID) {
 	ot.MarkDelete()
 }
 
-// StopRelay stops a relay and removes it from the manager.
+// SetSubscriberMuted applies a mute reason to the OutTrack of dstSID on the relay of srcSID.
+func (m *RelayManager) SetSubscriberMuted(srcSID, dstSID core.SessionID, reason MuteReason, muted bool) {
+	m.mu.RLock()
+	relay, ok := m.relays[srcSID]
+	m.mu.RUnlock()
+	if !ok {
+		return
+	}
+	relay.SetMuted(dstSID, reason, muted)
+}
+
+// SetSourceMuted applies a mute reason to everything the relay of srcSID forwards.
+func (m *RelayManager) SetSourceMuted(srcSID core.SessionID, reason MuteReason, muted bool) {
+	m.mu.RLock()
+	relay, ok := m.relays[srcSID]
+	m.mu.RUnlock()
+	if !ok {
+		return
+	}
+	relay.SetAllMuted(reason, muted)
+}
+
+// StopRelay stops a relay and removes it from the manager, together with a
+// parked track of srcSID.
 func (m *RelayManager) StopRelay(srcSID core.SessionID) {
 	m.mu.Lock()
 	relay, ok := m.relays[srcSID]
 	if ok {
 		delete(m.relays, srcSID)
 	}
+	delete(m.parked, srcSID)
 	m.mu.Unlock()
 	if !ok {
 		return
@@ -126,6 +195,13 @@ func (m *RelayManager) StopRelay(srcSID core.SessionID) {
 	relay.markAllDelete()
 }
 
+// Count returns the number of running relays.
+func (m *RelayManager) Count() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.relays)
+}
+
 func (m *RelayManager) HasRelay(sid core.SessionID) bool {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -10,13 +10,61 @@ import (
 )
 
 type Config struct {
-	Mode       string        `mapstructure:"mode"`
-	Port       int           `mapstructure:"port"`
-	StaticPath string        `mapstructure:"static_path"`
-	ReadLimit  int64         `mapstructure:"read_limit"`
-	PingPeriod time.Duration `mapstructure:"ping_period"`
-	Origin     string        `mapstructure:"origin"`
-	Secret     string        `mapstructure:"secret"`
+	Mode            string        `mapstructure:"mode"`
+	Port            int           `mapstructure:"port"`
+	StaticPath      string        `mapstructure:"static_path"`
+	ReadLimit       int64         `mapstructure:"read_limit"`
+	PingPeriod      time.Duration `mapstructure:"ping_period"`
+	Origin          string        `mapstructure:"origin"`
+	Secret          string        `mapstructure:"secret"`
+	EchoDelay       time.Duration `mapstructure:"echo_delay"`
+	FloorMaxHold    time.Duration `mapstructure:"floor_max_hold"`
+	ResumeGrace     time.Duration `mapstructure:"resume_grace"`
+	SingleDevice    bool          `mapstructure:"single_device"`
+	ChatHistory     int           `mapstructure:"chat_history"`
+	KickCooldown    time.Duration `mapstructure:"kick_cooldown"`
+	RoomIdleTTL     time.Duration `mapstructure:"room_idle_ttl"`
+	RoomEmptyGrace  time.Duration `mapstructure:"room_empty_grace"`
+	JanitorInterval time.Duration `mapstructure:"janitor_interval"`
+	UserTTL         time.Duration `mapstructure:"user_ttl"`
+	GuestTTL        time.Duration `mapstructure:"guest_ttl"`
+	// AdminRole is the identity role that may create persistent rooms.
+	AdminRole string `mapstructure:"admin_role"`
+	// TrustedProxies lists the addresses or CIDRs whose X-Forwarded-For is
+	// believed. Empty means the peer address is the client address.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// RoomStore is "memory" or "file"; the file lives at RoomStorePath.
+	RoomStore     string `mapstructure:"room_store"`
+	RoomStorePath string `mapstructure:"room_store_path"`
+	// UserStore is "memory" or "file"; the file lives at UserStorePath.
+	UserStore     string `mapstructure:"user_store"`
+	UserStorePath string `mapstructure:"user_store_path"`
+	// InviteStore is "memory" or "file"; the file lives at InviteStorePath.
+	InviteStore     string `mapstructure:"invite_store"`
+	InviteStorePath string `mapstructure:"invite_store_path"`
+	// Auth is "cookie" (guests and accounts) or "jwt" (bearer tokens).
+	Auth string `mapstructure:"auth"`
+	// AllowGuests lets clients without an account in under cookie auth.
+	AllowGuests bool `mapstructure:"allow_guests"`
+	// JWT settings, used when Auth is "jwt".
+	JWTAlg        string        `mapstructure:"jwt_alg"`
+	JWTKeyFile    string        `mapstructure:"jwt_key_file"`
+	JWTIssuer     string        `mapstructure:"jwt_issuer"`
+	JWTAudience   string        `mapstructure:"jwt_audience"`
+	JWTUserClaim  string        `mapstructure:"jwt_user_claim"`
+	JWTNameClaim  string        `mapstructure:"jwt_name_claim"`
+	JWTRolesClaim string        `mapstructure:"jwt_roles_claim"`
+	JWTLeeway     time.Duration `mapstructure:"jwt_leeway"`
+	// AuthHookURL, when set, is asked to allow every join and create_room.
+	AuthHookURL      string        `mapstructure:"auth_hook_url"`
+	AuthHookTimeout  time.Duration `mapstructure:"auth_hook_timeout"`
+	AuthHookFailOpen bool          `mapstructure:"auth_hook_fail_open"`
+	AuthHookCacheTTL time.Duration `mapstructure:"auth_hook_cache_ttl"`
+	// Capacity limits; zero is unlimited.
+	RoomMaxMembers int `mapstructure:"room_max_members"`
+	MaxSessions    int `mapstructure:"max_sessions"`
+	MaxPeers       int `mapstructure:"max_peers"`
+	MaxRelays      int `mapstructure:"max_relays"`
 }
 
 func Load() (*Config, error) {
@@ -35,9 +83,46 @@ func Load() (*Config, error) {
 
 	v.SetDefault("mode", "release")
 	v.SetDefault("port", 8080)
+	v.SetDefault("trusted_proxies", []string{})
+	v.SetDefault("admin_role", "admin")
 	v.SetDefault("static_path", "./web")
 	v.SetDefault("read_limit", 32768)
 	v.SetDefault("ping_period", "54s")
+	v.SetDefault("echo_delay", "1500ms")
+	v.SetDefault("floor_max_hold", "60s")
+	v.SetDefault("resume_grace", "30s")
+	v.SetDefault("single_device", false)
+	v.SetDefault("auth", "cookie")
+	v.SetDefault("allow_guests", true)
+	v.SetDefault("jwt_alg", "HS256")
+	v.SetDefault("jwt_key_file", "")
+	v.SetDefault("jwt_issuer", "")
+	v.SetDefault("jwt_audience", "")
+	v.SetDefault("jwt_user_claim", "sub")
+	v.SetDefault("jwt_name_claim", "name")
+	v.SetDefault("jwt_roles_claim", "roles")
+	v.SetDefault("jwt_leeway", "30s")
+	v.SetDefault("auth_hook_url", "")
+	v.SetDefault("auth_hook_timeout", "2s")
+	v.SetDefault("auth_hook_fail_open", false)
+	v.SetDefault("auth_hook_cache_ttl", "30s")
+	v.SetDefault("chat_history", 100)
+	v.SetDefault("kick_cooldown", "1m")
+	v.SetDefault("room_idle_ttl", "10m")
+	v.SetDefault("room_empty_grace", "30s")
+	v.SetDefault("janitor_interval", "10s")
+	v.SetDefault("user_ttl", "24h")
+	v.SetDefault("guest_ttl", "720h")
+	v.SetDefault("room_store", "memory")
+	v.SetDefault("room_store_path", "./data/rooms.json")
+	v.SetDefault("user_store", "memory")
+	v.SetDefault("user_store_path", "./data/users.json")
+	v.SetDefault("invite_store", "memory")
+	v.SetDefault("invite_store_path", "./data/invites.json")
+	v.SetDefault("room_max_members", 50)
+	v.SetDefault("max_sessions", 2000)
+	v.SetDefault("max_peers", 1000)
+	v.SetDefault("max_relays", 1000)
 
 	if err := v.ReadInConfig(); err != nil {
 		log.Warn().Str("file", fileName).Msg("Config file not found, using defaults")
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/dkeye/Voice/internal/domain"
+)
+
+// AccessAction is what a user asks to do to a room.
+type AccessAction string
+
+const (
+	AccessJoin   AccessAction = "join"
+	AccessCreate AccessAction = "create"
+)
+
+// AccessRequest describes a join or create for an Authorizer. Room is empty
+// on create; the room gets its ID only once it exists.
+type AccessRequest struct {
+	Action   AccessAction    `json:"action"`
+	User     domain.User     `json:"user"`
+	Login    string          `json:"login,omitempty"`
+	Roles    []string        `json:"roles,omitempty"`
+	Room     domain.RoomID   `json:"room,omitempty"`
+	RoomName domain.RoomName `json:"room_name,omitempty"`
+}
+
+// AccessDecision is an Authorizer's answer. Reason is shown to a denied user.
+type AccessDecision struct {
+	Allow  bool   `json:"allow"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Authorizer decides joins and room creation outside the server. An error
+// means no decision could be had; the caller treats it as a denial.
+type Authorizer interface {
+	Authorize(ctx context.Context, req AccessRequest) (AccessDecision, error)
+}
+
+// InviteUses is how often an invite has been redeemed. Expires is the
+// invite's own expiry, after which the count can be dropped.
+type InviteUses struct {
+	ID      string    `json:"id"`
+	Expires time.Time `json:"expires"`
+	Uses    int       `json:"uses"`
+}
+
+// InviteStore persists invite use counts so that max_uses holds across
+// restarts. Implementations must be safe for concurrent use.
+type InviteStore interface {
+	LoadInviteUses() ([]InviteUses, error)
+	SaveInviteUses(u InviteUses) error
+	DeleteInviteUses(id string) error
+}
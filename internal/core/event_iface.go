@@ -0,0 +1,132 @@
+package core
+
+import (
+	"time"
+
+	"github.com/dkeye/Voice/internal/domain"
+)
+
+// Event is a server-initiated notification produced by the app layer.
+// Transports translate events into their own wire messages.
+type Event interface {
+	isEvent()
+}
+
+// EventSink delivers events to connected clients.
+type EventSink interface {
+	Publish(ev Event)
+}
+
+// FloorReason tells why the push-to-talk floor changed hands.
+type FloorReason string
+
+const (
+	FloorRequested FloorReason = "requested"
+	FloorReleased  FloorReason = "released"
+	FloorExpired   FloorReason = "expired"
+	FloorOverride  FloorReason = "override"
+	FloorLeft      FloorReason = "left"
+)
+
+// FloorChanged carries the floor state of a room after any change.
+type FloorChanged struct {
+	Room   domain.RoomID
+	Holder *MemberDTO
+	Queue  []MemberDTO
+	Reason FloorReason
+}
+
+func (FloorChanged) isEvent() {}
+
+// WhisperChanged announces the start or end of a whisper to the people involved.
+type WhisperChanged struct {
+	Room    domain.RoomID
+	From    MemberDTO
+	Targets []MemberDTO
+	// To lists the sessions to notify: the speaker and the targets.
+	To     []SessionID
+	Active bool
+	// Updated marks an active whisper whose targets changed.
+	Updated bool
+}
+
+func (WhisperChanged) isEvent() {}
+
+// MemberChanged reports a change of a member's presence state. Changes holds
+// only the fields that differ from the previous state.
+type MemberChanged struct {
+	Room    domain.RoomID
+	User    domain.User
+	Changes MemberPatch
+}
+
+func (MemberChanged) isEvent() {}
+
+// RoomListChange tells lobby subscribers what happened to a public room.
+type RoomListChange string
+
+const (
+	RoomListCreated RoomListChange = "created"
+	RoomListUpdated RoomListChange = "updated"
+	RoomListClosed  RoomListChange = "closed"
+)
+
+// RoomListChanged is published for public rooms only.
+type RoomListChanged struct {
+	Room   RoomInfo
+	Change RoomListChange
+}
+
+func (RoomListChanged) isEvent() {}
+
+// MemberLeft reports a member the server removed without a command, such
+// as a reaped session. Members leaving on their own are announced by the
+// transport.
+type MemberLeft struct {
+	Room domain.RoomID
+	User domain.User
+}
+
+func (MemberLeft) isEvent() {}
+
+// KickReason tells a removed member why it had to leave.
+type KickReason string
+
+const (
+	KickedByModerator KickReason = "kicked"
+	KickedBanned      KickReason = "banned"
+	KickedSlow        KickReason = "slow_consumer"
+)
+
+// MemberKicked is sent to a session the server took out of its room. It is
+// published after the removal, so it reaches the kicked session only.
+type MemberKicked struct {
+	Room   domain.RoomID
+	SID    SessionID
+	Reason KickReason
+	// Note is the moderator's free-form explanation, if any.
+	Note string
+	// Until is set when the member is banned for a limited time.
+	Until *time.Time
+}
+
+func (MemberKicked) isEvent() {}
+
+// CloseReason tells why a room was closed.
+type CloseReason string
+
+const (
+	RoomClosedByOwner CloseReason = "closed_by_owner"
+	RoomClosedIdle    CloseReason = "idle"
+	RoomClosedEmpty   CloseReason = "empty"
+)
+
+// RoomClosed is published while the room still has its members, so they
+// can be told before they are removed. Lobby subscribers hear about public
+// rooms too.
+type RoomClosed struct {
+	Room   RoomInfo
+	Reason CloseReason
+}
+
+func (RoomClosed) isEvent() {}
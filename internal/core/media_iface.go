@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 
+	"github.com/dkeye/Voice/internal/domain"
 	"github.com/pion/webrtc/v4"
 )
 
@@ -14,15 +15,23 @@ type MediaConnection interface {
 	IsClosed() bool
 	// AddICECandidate applies a remote ICE candidate.
 	AddICECandidate(webrtc.ICECandidateInit) error
-	// LocalDescription returns the current local SDP.
+	// ApplyOffer and CreateAndSetAnswer answer an offer from the client,
+	// including a renegotiation of an established connection.
+	ApplyOffer(webrtc.SessionDescription) error
+	CreateAndSetAnswer() (*webrtc.SessionDescription, error)
+	// ApplyAnswer completes an offer made by CreateAndSetOffer.
 	ApplyAnswer(webrtc.SessionDescription) error
 	CreateAndSetOffer() (*webrtc.SessionDescription, error)
+	// Renegotiate makes the server send a fresh offer on this connection.
+	Renegotiate()
 	// OnICECandidate sets a callback for newly gathered local ICE candidates.
 	OnICECandidate(func(webrtc.ICECandidateInit))
 	// OnTrack sets a callback that will be invoked when a new remote track arrives.
 	OnTrack(func(ctx context.Context, track *webrtc.TrackRemote, receiver *webrtc.RTPReceiver))
 	// AddLocalTrack attaches a local static RTP track to the underlying PeerConnection.
 	AddLocalTrack(track *webrtc.TrackLocalStaticRTP) (*webrtc.RTPSender, error)
+	// OnQualityChange sets a callback for changes of the coarse connection quality.
+	OnQualityChange(func(domain.ConnQuality))
 	// OnClosed sets a callback for cleanup media session.
 	OnClosed(func())
 }
@@ -10,6 +10,8 @@ import (
 // memberSessionImpl implements MemberSession by pairing meta + transport.
 type memberSessionImpl struct {
 	meta *domain.Member
+	// uid is kept for logging; meta.User may be replaced under the room lock.
+	uid domain.UserID
 
 	smu    sync.RWMutex
 	signal SignalConnection
@@ -19,7 +21,7 @@ type memberSessionImpl struct {
 }
 
 func NewMemberSession(meta *domain.Member) MemberSession {
-	return &memberSessionImpl{meta: meta}
+	return &memberSessionImpl{meta: meta, uid: meta.User.ID}
 }
 
 func (m *memberSessionImpl) Meta() *domain.Member { return m.meta }
@@ -39,7 +41,7 @@ func (m *memberSessionImpl) UpdateSignal(signalConn SignalConnection) MemberSess
 	m.smu.Lock()
 	defer m.smu.Unlock()
 	m.signal = signalConn
-	log.Info().Str("module", "core.member").Str("user", string(m.meta.User.ID)).Msg("signal updated")
+	log.Info().Str("module", "core.member").Str("user", string(m.uid)).Msg("signal updated")
 	return m
 }
 
@@ -47,6 +49,6 @@ func (m *memberSessionImpl) UpdateMedia(mediaConn MediaConnection) MemberSession
 	m.mmu.Lock()
 	defer m.mmu.Unlock()
 	m.media = mediaConn
-	log.Info().Str("module", "core.member").Str("user", string(m.meta.User.ID)).Msg("media updated")
+	log.Info().Str("module", "core.member").Str("user", string(m.uid)).Msg("media updated")
 	return m
 }
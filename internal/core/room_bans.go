@@ -0,0 +1,48 @@
+package core
+
+import (
+	"slices"
+	"time"
+
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+func (r *roomImpl) Ban(b domain.Ban) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bans = slices.DeleteFunc(r.bans, func(x domain.Ban) bool { return x.User == b.User })
+	r.bans = append(r.bans, b)
+	log.Info().Str("module", "core.room").Str("user", string(b.User)).Bool("ip", b.ByIP).Msg("user banned")
+}
+
+func (r *roomImpl) Unban(uid domain.UserID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(r.bans)
+	r.bans = slices.DeleteFunc(r.bans, func(x domain.Ban) bool { return x.User == uid })
+	return len(r.bans) != n
+}
+
+func (r *roomImpl) BanOf(uid domain.UserID, ip string, now time.Time) (domain.Ban, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneBansLocked(now)
+	for _, b := range r.bans {
+		if b.Matches(uid, ip) {
+			return b, true
+		}
+	}
+	return domain.Ban{}, false
+}
+
+func (r *roomImpl) Bans(now time.Time) []domain.Ban {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneBansLocked(now)
+	return slices.Clone(r.bans)
+}
+
+func (r *roomImpl) pruneBansLocked(now time.Time) {
+	r.bans = slices.DeleteFunc(r.bans, func(b domain.Ban) bool { return !b.Active(now) })
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"slices"
+	"time"
+
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+func (r *roomImpl) PostChat(msg domain.ChatMessage, limit int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.chat = append(r.chat, msg)
+	if limit > 0 && len(r.chat) > limit {
+		r.chat = slices.Clone(r.chat[len(r.chat)-limit:])
+	}
+	log.Info().Str("module", "core.room").Str("msg", string(msg.ID)).Str("user", string(msg.Author.ID)).Msg("chat posted")
+}
+
+func (r *roomImpl) ChatMessage(id domain.ChatMessageID) (domain.ChatMessage, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if i := r.chatIndexLocked(id); i >= 0 {
+		return r.chat[i], true
+	}
+	return domain.ChatMessage{}, false
+}
+
+func (r *roomImpl) EditChat(id domain.ChatMessageID, text string) (domain.ChatMessage, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.chatIndexLocked(id)
+	if i < 0 {
+		return domain.ChatMessage{}, false
+	}
+	now := time.Now().UTC()
+	r.chat[i].Text = text
+	r.chat[i].EditedAt = &now
+	log.Info().Str("module", "core.room").Str("msg", string(id)).Msg("chat edited")
+	return r.chat[i], true
+}
+
+func (r *roomImpl) DeleteChat(id domain.ChatMessageID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.chatIndexLocked(id)
+	if i < 0 {
+		return false
+	}
+	r.chat = slices.Delete(r.chat, i, i+1)
+	log.Info().Str("module", "core.room").Str("msg", string(id)).Msg("chat deleted")
+	return true
+}
+
+func (r *roomImpl) ChatHistory() []domain.ChatMessage {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.chat)
+}
+
+func (r *roomImpl) chatIndexLocked(id domain.ChatMessageID) int {
+	return slices.IndexFunc(r.chat, func(m domain.ChatMessage) bool { return m.ID == id })
+}
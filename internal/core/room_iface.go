@@ -1,6 +1,8 @@
 package core
 
 import (
+	"time"
+
 	"github.com/dkeye/Voice/internal/domain"
 )
 
@@ -12,8 +14,68 @@ type PublishResult struct {
 
 // MemberDTO is a read-only view for APIs (no transport fields).
 type MemberDTO struct {
-	ID       domain.UserID `json:"id"`
-	Username string        `json:"username"`
+	ID         domain.UserID      `json:"id"`
+	Username   string             `json:"username"`
+	Role       domain.StageRole   `json:"role,omitempty"`
+	RoomRole   domain.RoomRole    `json:"room_role,omitempty"`
+	Muted      bool               `json:"muted,omitempty"`
+	ForceMuted bool               `json:"force_muted,omitempty"`
+	Deafened   bool               `json:"deafened,omitempty"`
+	HandRaised bool               `json:"hand_raised,omitempty"`
+	Media      bool               `json:"media,omitempty"`
+	Quality    domain.ConnQuality `json:"quality,omitempty"`
+}
+
+// User returns the user the member view belongs to.
+func (m MemberDTO) User() domain.User {
+	return domain.User{ID: m.ID, Username: m.Username}
+}
+
+// MemberPatch lists the member fields that changed; nil means unchanged.
+type MemberPatch struct {
+	Username   *string             `json:"username,omitempty"`
+	Role       *domain.StageRole   `json:"role,omitempty"`
+	RoomRole   *domain.RoomRole    `json:"room_role,omitempty"`
+	Muted      *bool               `json:"muted,omitempty"`
+	ForceMuted *bool               `json:"force_muted,omitempty"`
+	Deafened   *bool               `json:"deafened,omitempty"`
+	HandRaised *bool               `json:"hand_raised,omitempty"`
+	Media      *bool               `json:"media,omitempty"`
+	Quality    *domain.ConnQuality `json:"quality,omitempty"`
+}
+
+// DiffMember returns the fields of after that differ from before.
+func DiffMember(before, after MemberDTO) (MemberPatch, bool) {
+	var p MemberPatch
+	changed := false
+	if before.Username != after.Username {
+		p.Username, changed = &after.Username, true
+	}
+	if before.Role != after.Role {
+		p.Role, changed = &after.Role, true
+	}
+	if before.RoomRole != after.RoomRole {
+		p.RoomRole, changed = &after.RoomRole, true
+	}
+	if before.Muted != after.Muted {
+		p.Muted, changed = &after.Muted, true
+	}
+	if before.ForceMuted != after.ForceMuted {
+		p.ForceMuted, changed = &after.ForceMuted, true
+	}
+	if before.Deafened != after.Deafened {
+		p.Deafened, changed = &after.Deafened, true
+	}
+	if before.HandRaised != after.HandRaised {
+		p.HandRaised, changed = &after.HandRaised, true
+	}
+	if before.Media != after.Media {
+		p.Media, changed = &after.Media, true
+	}
+	if before.Quality != after.Quality {
+		p.Quality, changed = &after.Quality, true
+	}
+	return p, changed
 }
 
 // RoomService is the core-facing API of a room.
@@ -23,19 +85,122 @@ type RoomService interface {
 	MemberCount() int
 	MembersSnapshot() []MemberDTO
 
-	AddMember(sid SessionID, ms MemberSession)
+	// Phase returns the lifecycle phase and when the room entered it.
+	Phase() (domain.RoomPhase, time.Time)
+	// Record returns the room's persistent definition.
+	Record() RoomRecord
+	// OnRecordChange registers fn to receive the record after every change
+	// to the room's definition, such as a role change.
+	OnRecordChange(fn func(RoomRecord))
+	// Close moves the room to RoomClosed. It reports false if the room was
+	// already closed, so only one caller tears it down.
+	Close() bool
+
+	// AddMember adds sid unless the room is closed or already holds
+	// MaxMembers; the check and the insert happen under one lock. It
+	// reports whether sid was added.
+	AddMember(sid SessionID, ms MemberSession) bool
+	// ReplaceMember hands the membership of old to sid, another session of
+	// the same user. The member keeps its roles, mute, deafen and hand, its
+	// place in the join order and hand queue, and its whispers. It reports
+	// false if old is not a member, belongs to another user, or the room
+	// is closed.
+	ReplaceMember(old, sid SessionID, ms MemberSession) bool
 	RemoveMember(sid SessionID)
+	Member(sid SessionID) (MemberSession, bool)
+	// MemberDTO returns a consistent view of one member's state.
+	MemberDTO(sid SessionID) (MemberDTO, bool)
+	SessionOf(uid domain.UserID) (SessionID, bool)
 	Broadcast(from SessionID, data Frame) PublishResult
+
+	// RoleOf returns the room role of uid, RoleMember if it has none.
+	RoleOf(uid domain.UserID) domain.RoomRole
+	// IsModerator reports whether uid is the owner or a moderator.
+	IsModerator(uid domain.UserID) bool
+	// GrantRole records a role for a user who is not in the room, such as
+	// the creator. Members change roles through UpdateMember.
+	GrantRole(uid domain.UserID, role domain.RoomRole)
+	// Successor picks who inherits ownership: the longest-present moderator,
+	// otherwise the longest-present member.
+	Successor() (SessionID, bool)
+
+	// UpdateMember applies fn to the member's state under the room lock and
+	// returns the views before and after; false if sid is not a member.
+	UpdateMember(sid SessionID, fn func(m *domain.Member)) (before, after MemberDTO, ok bool)
+	// HandQueue returns queued members in the order they raised their hands.
+	HandQueue() []MemberDTO
+
+	// StartWhisper limits who hears from to the given members; false if from is not a member.
+	StartWhisper(from SessionID, to []SessionID) bool
+	// EndWhisper stops the whisper of from and returns its targets.
+	EndWhisper(from SessionID) ([]SessionID, bool)
+	// Whispers returns a copy of the active whispers keyed by speaker.
+	Whispers() map[SessionID][]SessionID
+
+	// PostChat appends msg to the history, keeping at most limit messages.
+	PostChat(msg domain.ChatMessage, limit int)
+	ChatMessage(id domain.ChatMessageID) (domain.ChatMessage, bool)
+	// EditChat replaces the text of a message and stamps it as edited.
+	EditChat(id domain.ChatMessageID, text string) (domain.ChatMessage, bool)
+	DeleteChat(id domain.ChatMessageID) bool
+	// ChatHistory returns the retained messages, oldest first.
+	ChatHistory() []domain.ChatMessage
+
+	// Ban adds b to the ban list, replacing any ban of the same user.
+	Ban(b domain.Ban)
+	// Unban lifts the ban of uid and reports whether there was one.
+	Unban(uid domain.UserID) bool
+	// BanOf returns the active ban covering uid or ip. Expired bans are dropped.
+	BanOf(uid domain.UserID, ip string, now time.Time) (domain.Ban, bool)
+	// Bans returns the active bans, oldest first.
+	Bans(now time.Time) []domain.Ban
 }
 
 type RoomInfo struct {
 	ID          domain.RoomID   `json:"id"`
 	Name        domain.RoomName `json:"name"`
+	Mode        domain.RoomMode `json:"mode"`
+	Public      bool            `json:"-"`
+	Locked      bool            `json:"locked,omitempty"`
 	MemberCount int             `json:"client_count"`
+	MaxMembers  int             `json:"max_members,omitempty"`
+}
+
+// InfoOf summarizes a room for listings.
+func InfoOf(r RoomService) RoomInfo {
+	room := r.Room()
+	return RoomInfo{
+		ID:          room.ID,
+		Name:        room.Name,
+		Mode:        room.Settings.Mode,
+		Public:      room.IsPublic(),
+		Locked:      room.IsLocked(),
+		MemberCount: r.MemberCount(),
+		MaxMembers:  room.Settings.MaxMembers,
+	}
+}
+
+// RoomRecord is what a RoomStore keeps of a room: its definition, not who
+// is in it.
+type RoomRecord struct {
+	ID       domain.RoomID       `json:"id"`
+	Name     domain.RoomName     `json:"name"`
+	Settings domain.RoomSettings `json:"settings"`
+	// PasswordHash is kept apart because RoomSettings never serializes it.
+	PasswordHash []byte                            `json:"password_hash,omitempty"`
+	Roles        map[domain.UserID]domain.RoomRole `json:"roles,omitempty"`
+}
+
+// RoomStore persists room definitions across restarts. Implementations
+// must be safe for concurrent use.
+type RoomStore interface {
+	LoadRooms() ([]RoomRecord, error)
+	SaveRoom(rec RoomRecord) error
+	DeleteRoom(id domain.RoomID) error
 }
 
 type RoomManager interface {
-	CreateRoom(name domain.RoomName) RoomService
+	CreateRoom(name domain.RoomName, settings domain.RoomSettings) RoomService
 	GetRoom(id domain.RoomID) (RoomService, bool)
 	List() []RoomInfo
 	StopRoom(name domain.RoomID)
@@ -1,7 +1,9 @@
 package core
 
 import (
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/dkeye/Voice/internal/domain"
 	"github.com/google/uuid"
@@ -14,17 +16,45 @@ type roomImpl struct {
 	mu     sync.RWMutex
 	bySID  map[SessionID]MemberSession
 	byUser map[domain.UserID]SessionID
+
+	roles    map[domain.UserID]domain.RoomRole
+	order    []SessionID
+	hands    []SessionID
+	whispers map[SessionID][]SessionID
+	chat     []domain.ChatMessage
+	bans     []domain.Ban
+
+	phase      domain.RoomPhase
+	phaseSince time.Time
+
+	// onRecord receives the room's record whenever its definition changes.
+	onRecord func(RoomRecord)
 }
 
-func NewRoomService(roomName domain.RoomName) RoomService {
-	newRoom := &domain.Room{
-		ID:   domain.RoomID(uuid.NewString()),
-		Name: roomName,
+func NewRoomService(roomName domain.RoomName, settings domain.RoomSettings) RoomService {
+	if settings.Mode == "" {
+		settings.Mode = domain.RoomModeConference
+	}
+	if settings.Visibility == "" {
+		settings.Visibility = domain.RoomPrivate
 	}
+	return newRoomImpl(&domain.Room{
+		ID:       domain.RoomID(uuid.NewString()),
+		Name:     roomName,
+		Settings: settings,
+	})
+}
+
+func newRoomImpl(room *domain.Room) *roomImpl {
 	return &roomImpl{
-		room:   newRoom,
-		bySID:  make(map[SessionID]MemberSession),
-		byUser: make(map[domain.UserID]SessionID),
+		room:     room,
+		bySID:    make(map[SessionID]MemberSession),
+		byUser:   make(map[domain.UserID]SessionID),
+		roles:    make(map[domain.UserID]domain.RoomRole),
+		whispers: make(map[SessionID][]SessionID),
+
+		phase:      domain.RoomCreated,
+		phaseSince: time.Now(),
 	}
 }
 
@@ -36,13 +66,70 @@ func (r *roomImpl) MemberCount() int {
 	return len(r.bySID)
 }
 
-func (r *roomImpl) AddMember(sid SessionID, ms MemberSession) {
-	u := ms.Meta().User.ID
+func (r *roomImpl) AddMember(sid SessionID, ms MemberSession) bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if r.phase == domain.RoomClosed {
+		return false
+	}
+	u := ms.Meta().User.ID
+	if max := r.room.Settings.MaxMembers; max > 0 && len(r.bySID) >= max {
+		if _, ok := r.bySID[sid]; !ok {
+			return false
+		}
+	}
+	ms.Meta().RoomRole = r.roleLocked(u)
 	r.bySID[sid] = ms
 	r.byUser[u] = sid
+	r.order = append(r.order, sid)
+	r.setPhaseLocked(domain.RoomActive)
 	log.Info().Str("module", "core.room").Str("sid", string(sid)).Str("user", string(u)).Msg("member added")
+	return true
+}
+
+func (r *roomImpl) ReplaceMember(old, sid SessionID, ms MemberSession) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prev, ok := r.bySID[old]
+	if !ok || old == sid || r.phase == domain.RoomClosed {
+		return false
+	}
+	from, to := prev.Meta(), ms.Meta()
+	if from.User.ID != to.User.ID {
+		return false
+	}
+	to.RoomRole = from.RoomRole
+	to.StageRole = from.StageRole
+	to.Mute = from.Mute
+	to.Deafened = from.Deafened
+	to.ForceMuted = from.ForceMuted
+	to.HandRaised = from.HandRaised
+	delete(r.bySID, old)
+	r.bySID[sid] = ms
+	r.byUser[to.User.ID] = sid
+	swap := func(s SessionID) SessionID {
+		if s == old {
+			return sid
+		}
+		return s
+	}
+	for i, s := range r.order {
+		r.order[i] = swap(s)
+	}
+	for i, s := range r.hands {
+		r.hands[i] = swap(s)
+	}
+	if targets, ok := r.whispers[old]; ok {
+		delete(r.whispers, old)
+		r.whispers[sid] = targets
+	}
+	for _, targets := range r.whispers {
+		for i, s := range targets {
+			targets[i] = swap(s)
+		}
+	}
+	log.Info().Str("module", "core.room").Str("old_sid", string(old)).Str("sid", string(sid)).Str("user", string(to.User.ID)).Msg("member moved to another session")
+	return true
 }
 
 func (r *roomImpl) RemoveMember(sid SessionID) {
@@ -53,6 +140,12 @@ func (r *roomImpl) RemoveMember(sid SessionID) {
 		delete(r.byUser, u)
 	}
 	delete(r.bySID, sid)
+	r.order = slices.DeleteFunc(r.order, func(s SessionID) bool { return s == sid })
+	r.removeHandLocked(sid)
+	r.removeWhispersLocked(sid)
+	if len(r.bySID) == 0 {
+		r.setPhaseLocked(domain.RoomEmpty)
+	}
 	log.Info().Str("module", "core.room").Str("sid", string(sid)).Msg("member removed")
 }
 
@@ -79,8 +172,75 @@ func (r *roomImpl) MembersSnapshot() []MemberDTO {
 	defer r.mu.RUnlock()
 	out := make([]MemberDTO, 0, len(r.bySID))
 	for _, ms := range r.bySID {
-		u := ms.Meta().User
-		out = append(out, MemberDTO{ID: u.ID, Username: u.Username})
+		out = append(out, memberDTO(ms))
 	}
 	return out
 }
+
+func (r *roomImpl) Member(sid SessionID) (MemberSession, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ms, ok := r.bySID[sid]
+	return ms, ok
+}
+
+func (r *roomImpl) MemberDTO(sid SessionID) (MemberDTO, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ms, ok := r.bySID[sid]
+	if !ok {
+		return MemberDTO{}, false
+	}
+	return memberDTO(ms), true
+}
+
+func (r *roomImpl) SessionOf(uid domain.UserID) (SessionID, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sid, ok := r.byUser[uid]
+	return sid, ok
+}
+
+func memberDTO(ms MemberSession) MemberDTO {
+	meta := ms.Meta()
+	return MemberDTO{
+		ID:         meta.User.ID,
+		Username:   meta.User.Username,
+		Role:       meta.StageRole,
+		RoomRole:   meta.RoomRole,
+		Muted:      meta.Mute,
+		ForceMuted: meta.ForceMuted,
+		Deafened:   meta.Deafened,
+		HandRaised: meta.HandRaised,
+		Media:      meta.MediaConnected,
+		Quality:    meta.Quality,
+	}
+}
+
+func (r *roomImpl) UpdateMember(sid SessionID, fn func(m *domain.Member)) (before, after MemberDTO, ok bool) {
+	defer func() {
+		if ok && after.RoomRole != before.RoomRole {
+			r.recordChanged()
+		}
+	}()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ms, ok := r.bySID[sid]
+	if !ok {
+		return MemberDTO{}, MemberDTO{}, false
+	}
+	before = memberDTO(ms)
+	fn(ms.Meta())
+	after = memberDTO(ms)
+	if after.RoomRole != before.RoomRole {
+		r.setRoleLocked(after.ID, after.RoomRole)
+	}
+	// The hand queue follows the flag so that raising and lowering hands
+	// goes through the same path as every other state change.
+	if after.HandRaised && !before.HandRaised {
+		r.hands = append(r.hands, sid)
+	} else if !after.HandRaised {
+		r.removeHandLocked(sid)
+	}
+	return before, after, true
+}
@@ -0,0 +1,33 @@
+package core
+
+import (
+	"time"
+
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+func (r *roomImpl) Phase() (domain.RoomPhase, time.Time) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.phase, r.phaseSince
+}
+
+func (r *roomImpl) Close() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.phase == domain.RoomClosed {
+		return false
+	}
+	r.setPhaseLocked(domain.RoomClosed)
+	return true
+}
+
+// setPhaseLocked moves the room to phase. A closed room stays closed.
+func (r *roomImpl) setPhaseLocked(phase domain.RoomPhase) {
+	if r.phase == phase || r.phase == domain.RoomClosed {
+		return
+	}
+	r.phase, r.phaseSince = phase, time.Now()
+	log.Debug().Str("module", "core.room").Str("room_id", string(r.room.ID)).Str("phase", string(phase)).Msg("room phase")
+}
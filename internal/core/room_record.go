@@ -0,0 +1,49 @@
+package core
+
+import (
+	"maps"
+
+	"github.com/dkeye/Voice/internal/domain"
+)
+
+// RestoreRoomService rebuilds a room from its stored record. It starts
+// without members, like a freshly created room.
+func RestoreRoomService(rec RoomRecord) RoomService {
+	settings := rec.Settings
+	settings.PasswordHash = rec.PasswordHash
+	r := newRoomImpl(&domain.Room{ID: rec.ID, Name: rec.Name, Settings: settings})
+	maps.Copy(r.roles, rec.Roles)
+	return r
+}
+
+func (r *roomImpl) Record() RoomRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.recordLocked()
+}
+
+func (r *roomImpl) OnRecordChange(fn func(RoomRecord)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onRecord = fn
+}
+
+func (r *roomImpl) recordLocked() RoomRecord {
+	return RoomRecord{
+		ID:           r.room.ID,
+		Name:         r.room.Name,
+		Settings:     r.room.Settings,
+		PasswordHash: r.room.Settings.PasswordHash,
+		Roles:        maps.Clone(r.roles),
+	}
+}
+
+// recordChanged hands the current record to the OnRecordChange hook.
+func (r *roomImpl) recordChanged() {
+	r.mu.RLock()
+	fn, rec := r.onRecord, r.recordLocked()
+	r.mu.RUnlock()
+	if fn != nil {
+		fn(rec)
+	}
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"github.com/dkeye/Voice/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+func (r *roomImpl) RoleOf(uid domain.UserID) domain.RoomRole {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.roleLocked(uid)
+}
+
+func (r *roomImpl) IsModerator(uid domain.UserID) bool {
+	return r.RoleOf(uid).CanModerate()
+}
+
+func (r *roomImpl) GrantRole(uid domain.UserID, role domain.RoomRole) {
+	r.mu.Lock()
+	r.setRoleLocked(uid, role)
+	r.mu.Unlock()
+	r.recordChanged()
+}
+
+func (r *roomImpl) Successor() (SessionID, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, sid := range r.order {
+		if r.bySID[sid].Meta().RoomRole == domain.RoleModerator {
+			return sid, true
+		}
+	}
+	if len(r.order) > 0 {
+		return r.order[0], true
+	}
+	return "", false
+}
+
+func (r *roomImpl) roleLocked(uid domain.UserID) domain.RoomRole {
+	if role, ok := r.roles[uid]; ok {
+		return role
+	}
+	return domain.RoleMember
+}
+
+// setRoleLocked records role for uid; plain members are not stored.
+func (r *roomImpl) setRoleLocked(uid domain.UserID, role domain.RoomRole) {
+	if role == domain.RoleMember || role == "" {
+		delete(r.roles, uid)
+	} else {
+		r.roles[uid] = role
+	}
+	log.Info().Str("module", "core.room").Str("user", string(uid)).Str("role", string(role)).Msg("room role changed")
+}
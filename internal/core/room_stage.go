@@ -0,0 +1,26 @@
+package core
+
+import (
+	"slices"
+)
+
+func (r *roomImpl) HandQueue() []MemberDTO {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]MemberDTO, 0, len(r.hands))
+	for _, sid := range r.hands {
+		if ms, ok := r.bySID[sid]; ok {
+			out = append(out, memberDTO(ms))
+		}
+	}
+	return out
+}
+
+func (r *roomImpl) removeHandLocked(sid SessionID) bool {
+	i := slices.Index(r.hands, sid)
+	if i < 0 {
+		return false
+	}
+	r.hands = slices.Delete(r.hands, i, i+1)
+	return true
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"slices"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (r *roomImpl) StartWhisper(from SessionID, to []SessionID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.bySID[from]; !ok {
+		return false
+	}
+	targets := make([]SessionID, 0, len(to))
+	for _, sid := range to {
+		if _, ok := r.bySID[sid]; ok && sid != from && !slices.Contains(targets, sid) {
+			targets = append(targets, sid)
+		}
+	}
+	if len(targets) == 0 {
+		return false
+	}
+	r.whispers[from] = targets
+	log.Info().Str("module", "core.room").Str("sid", string(from)).Int("targets", len(targets)).Msg("whisper started")
+	return true
+}
+
+func (r *roomImpl) EndWhisper(from SessionID) ([]SessionID, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	targets, ok := r.whispers[from]
+	if ok {
+		delete(r.whispers, from)
+		log.Info().Str("module", "core.room").Str("sid", string(from)).Msg("whisper ended")
+	}
+	return targets, ok
+}
+
+func (r *roomImpl) Whispers() map[SessionID][]SessionID {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[SessionID][]SessionID, len(r.whispers))
+	for from, targets := range r.whispers {
+		out[from] = slices.Clone(targets)
+	}
+	return out
+}
+
+// removeWhispersLocked drops sid's own whisper and removes sid from other
+// whispers; a whisper left without targets ends.
+func (r *roomImpl) removeWhispersLocked(sid SessionID) {
+	delete(r.whispers, sid)
+	for from, targets := range r.whispers {
+		targets = slices.DeleteFunc(targets, func(t SessionID) bool { return t == sid })
+		if len(targets) == 0 {
+			delete(r.whispers, from)
+			continue
+		}
+		r.whispers[from] = targets
+	}
+}
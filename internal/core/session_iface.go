@@ -1,9 +1,40 @@
 package core
 
-import "github.com/dkeye/Voice/internal/domain"
+import (
+	"time"
 
+	"github.com/dkeye/Voice/internal/domain"
+)
+
+// SessionID identifies one signal connection's session: a single device or
+// tab. It carries its own room membership and media.
 type SessionID string
 
+// ClientID identifies a client by its ct cookie, account or token subject.
+// A client owns one user and may hold several sessions at once.
+type ClientID string
+
+// Identity is what an authenticator established about a request.
+type Identity struct {
+	Client ClientID
+	// User and Name are set when an external issuer fixes the user, as a
+	// JWT does; otherwise the registry picks the user behind Client.
+	User  domain.UserID
+	Name  string
+	Roles []string
+}
+
+// UserStore keeps user profiles keyed by a stable identity, such as the
+// client cookie. Implementations must be safe for concurrent use.
+type UserStore interface {
+	// LoadUser reports false when key has no profile yet.
+	LoadUser(key string) (domain.UserProfile, bool, error)
+	SaveUser(key string, p domain.UserProfile) error
+	// ExpireGuests deletes guest profiles last seen before the given time,
+	// except those keep reports true for, and returns how many went.
+	ExpireGuests(before time.Time, keep func(key string) bool) (int, error)
+}
+
 // MemberSession binds domain.Member and its transport endpoint.
 // This is what a room stores and fans out to.
 type MemberSession interface {
@@ -8,4 +8,6 @@ type Frame []byte
 type SignalConnection interface {
 	TrySend(Frame) error
 	Close()
+	// IsClosed reports whether Close has run, locally or after the peer hung up.
+	IsClosed() bool
 }
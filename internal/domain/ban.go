@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// Ban keeps a user, and optionally every connection from the user's IP,
+// out of a room.
+type Ban struct {
+	User UserID `json:"user"`
+	// IP is never sent to clients; ByIP tells them it is set.
+	IP     string    `json:"-"`
+	ByIP   bool      `json:"ip,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+	By     UserID    `json:"by"`
+	At     time.Time `json:"at"`
+	// Until is nil for a permanent ban.
+	Until *time.Time `json:"until,omitempty"`
+}
+
+// Active reports whether the ban still applies at now.
+func (b Ban) Active(now time.Time) bool {
+	return b.Until == nil || now.Before(*b.Until)
+}
+
+// Matches reports whether the ban covers uid connecting from ip.
+func (b Ban) Matches(uid UserID, ip string) bool {
+	return b.User == uid || (b.ByIP && ip != "" && b.IP == ip)
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const MaxChatTextLen = 2000
+
+var (
+	ErrChatTextEmpty   = errors.New("chat text empty")
+	ErrChatTextTooLong = errors.New("chat text too long")
+)
+
+type ChatMessageID string
+
+// ChatMessage is one text message posted to a room.
+type ChatMessage struct {
+	ID       ChatMessageID `json:"id"`
+	Author   User          `json:"author"`
+	Text     string        `json:"text"`
+	At       time.Time     `json:"at"`
+	EditedAt *time.Time    `json:"edited_at,omitempty"`
+}
+
+// NewChatMessage stamps a message with a fresh ID and the current time.
+func NewChatMessage(author User, text string) (*ChatMessage, error) {
+	if err := ValidateChatText(text); err != nil {
+		return nil, err
+	}
+	return &ChatMessage{
+		ID:     ChatMessageID(uuid.NewString()),
+		Author: author,
+		Text:   text,
+		At:     time.Now().UTC(),
+	}, nil
+}
+
+func ValidateChatText(text string) error {
+	if len(text) == 0 {
+		return ErrChatTextEmpty
+	}
+	if len(text) > MaxChatTextLen {
+		return ErrChatTextTooLong
+	}
+	return nil
+}
@@ -1,14 +1,83 @@
 package domain
 
+// StageRole is a member's role in a stage-mode room.
+type StageRole string
+
+const (
+	StageSpeaker  StageRole = "speaker"
+	StageAudience StageRole = "audience"
+)
+
+// RoomRole is a user's standing in a room. It is kept by the room across
+// rejoins, unlike the rest of Member.
+type RoomRole string
+
+const (
+	RoleOwner     RoomRole = "owner"
+	RoleModerator RoomRole = "moderator"
+	RoleMember    RoomRole = "member"
+)
+
+// Rank orders roles so that a higher rank may act on a lower one.
+func (r RoomRole) Rank() int {
+	switch r {
+	case RoleOwner:
+		return 2
+	case RoleModerator:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// CanModerate reports whether the role may run moderation commands.
+func (r RoomRole) CanModerate() bool {
+	return r.Rank() >= RoleModerator.Rank()
+}
+
+// ConnQuality is a coarse rating of a member's media connection.
+type ConnQuality string
+
+const (
+	QualityGood ConnQuality = "good"
+	QualityFair ConnQuality = "fair"
+	QualityPoor ConnQuality = "poor"
+)
+
 // Member represents user's participation meta for a room.
 // No transport or lifecycle logic here.
 type Member struct {
+	// User is shared with the registry and never changed in place; a
+	// rename replaces it, under the room lock while the member is in a room.
 	User *User
+	// Mute is the member's own microphone switch as reported by the client.
 	Mute bool
-	// role, mute, anon, etc. could go here later
+	// Deafened members receive no audio; the server stops forwarding to them.
+	Deafened bool
+	// StageRole is empty outside of stage-mode rooms.
+	StageRole StageRole
+	// RoomRole is filled in by the room on join.
+	RoomRole RoomRole
+	// ForceMuted is set by a moderator; the server stops forwarding the
+	// member's audio until it is cleared.
+	ForceMuted bool
+	// HandRaised mirrors the member's place in the stage hand queue.
+	HandRaised bool
+	// MediaConnected is set while the member has a live WebRTC connection.
+	MediaConnected bool
+	// Quality is empty until the media connection has been rated.
+	Quality ConnQuality
+	// ClientIP is the address the session connected from; IP bans match it.
+	ClientIP string
+	// anon, etc. could go here later
 }
 
 // NewMember avoids raw literals in adapters and keeps construction obvious.
 func NewMember(user *User) *Member {
 	return &Member{User: user}
 }
+
+// CanSpeak reports whether the role may publish upstream audio.
+func (r StageRole) CanSpeak() bool {
+	return r != StageAudience
+}
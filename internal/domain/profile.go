@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// UserPrefs are the user's own client settings. The server stores them
+// and hands them back; clients apply them.
+type UserPrefs struct {
+	// StartMuted joins rooms with the microphone off.
+	StartMuted bool `json:"start_muted,omitempty"`
+	// StartDeafened joins rooms with incoming audio off.
+	StartDeafened bool `json:"start_deafened,omitempty"`
+}
+
+// UserProfile is what a user store keeps of a user across restarts.
+type UserProfile struct {
+	ID        UserID    `json:"id"`
+	Username  string    `json:"username"`
+	Prefs     UserPrefs `json:"prefs"`
+	CreatedAt time.Time `json:"created_at"`
+	// SeenAt is when the profile was last in use; stale guests expire by it.
+	SeenAt time.Time `json:"seen_at,omitempty"`
+	// Roles are the last roles an external issuer vouched for.
+	Roles []string `json:"roles,omitempty"`
+	// Login and PasswordHash are set for registered accounts only.
+	Login        string `json:"login,omitempty"`
+	PasswordHash []byte `json:"password_hash,omitempty"`
+}
+
+// IsGuest reports whether the profile has no account behind it.
+func (p UserProfile) IsGuest() bool { return p.Login == "" }
@@ -1,11 +1,81 @@
 package domain
 
+import "time"
+
 type (
-	RoomName string
-	RoomID   string
+	RoomName       string
+	RoomID         string
+	RoomMode       string
+	RoomVisibility string
+)
+
+const (
+	// RoomModeConference is the default mode: every member may speak.
+	RoomModeConference RoomMode = "conference"
+	// RoomModeStage splits members into speakers and a receive-only audience.
+	RoomModeStage RoomMode = "stage"
+)
+
+const (
+	// RoomPrivate rooms are reachable only by ID; this is the default.
+	RoomPrivate RoomVisibility = "private"
+	// RoomPublic rooms are listed in the lobby.
+	RoomPublic RoomVisibility = "public"
 )
 
+// EchoRoomID is the reserved room ID of the loopback echo test.
+const EchoRoomID RoomID = "echo"
+
+// RoomSettings holds options chosen at room creation.
+type RoomSettings struct {
+	Mode       RoomMode       `json:"mode"`
+	Visibility RoomVisibility `json:"visibility"`
+	// FloorControl enables push-to-talk: only the floor holder is heard.
+	FloorControl bool `json:"floor_control,omitempty"`
+	// FloorMaxHold limits how long one speaker keeps the floor; zero uses the server default.
+	FloorMaxHold time.Duration `json:"floor_max_hold,omitempty"`
+	// PasswordHash, when set, is the bcrypt hash joiners must match.
+	PasswordHash []byte `json:"-"`
+	// InviteOnly rooms admit only holders of a valid invite token.
+	InviteOnly bool `json:"invite_only,omitempty"`
+	// MaxMembers caps how many members the room holds; zero is unlimited.
+	MaxMembers int `json:"max_members,omitempty"`
+	// Persistent rooms survive restarts with the same ID and are never
+	// closed for being idle or empty.
+	Persistent bool `json:"persistent,omitempty"`
+}
+
 type Room struct {
-	ID   RoomID
-	Name RoomName
+	ID       RoomID
+	Name     RoomName
+	Settings RoomSettings
+}
+
+// IsLocked reports whether joining needs a password or an invite.
+func (r *Room) IsLocked() bool {
+	return len(r.Settings.PasswordHash) > 0 || r.Settings.InviteOnly
+}
+
+// RoomPhase is where a room is in its lifecycle.
+type RoomPhase string
+
+const (
+	// RoomCreated: nobody has joined yet.
+	RoomCreated RoomPhase = "created"
+	// RoomActive: at least one member is in the room.
+	RoomActive RoomPhase = "active"
+	// RoomEmpty: the last member left; the room waits out a grace period.
+	RoomEmpty RoomPhase = "empty"
+	// RoomClosed: the room is being torn down and admits nobody.
+	RoomClosed RoomPhase = "closed"
+)
+
+// IsPublic reports whether the room is listed in the lobby.
+func (r *Room) IsPublic() bool {
+	return r.Settings.Visibility == RoomPublic
+}
+
+// IsStage reports whether the room runs in stage mode.
+func (r *Room) IsStage() bool {
+	return r.Settings.Mode == RoomModeStage
 }
@@ -0,0 +1,110 @@
+package protocol
+
+import "github.com/dkeye/Voice/internal/domain"
+
+// CreateRoom asks the server for a new room. FloorMaxHold is in seconds.
+type CreateRoom struct {
+	Header
+	Name         string          `json:"name"`
+	Mode         domain.RoomMode `json:"mode,omitempty"`
+	FloorControl bool            `json:"floor_control,omitempty"`
+	FloorMaxHold int             `json:"floor_max_hold,omitempty"`
+	// Visibility defaults to private; public rooms appear in list_rooms.
+	Visibility domain.RoomVisibility `json:"visibility,omitempty"`
+	// Password, if set, must be given by everyone joining without an invite.
+	Password string `json:"password,omitempty"`
+	// InviteOnly admits only holders of an invite token.
+	InviteOnly bool `json:"invite_only,omitempty"`
+	// MaxMembers caps the room below the server default; zero takes the default.
+	MaxMembers int `json:"max_members,omitempty"`
+	// Persistent rooms keep their ID across restarts and never expire.
+	Persistent bool `json:"persistent,omitempty"`
+}
+
+type Join struct {
+	Header
+	Room     domain.RoomID `json:"room"`
+	Name     string        `json:"name,omitempty"`
+	Password string        `json:"password,omitempty"`
+	Invite   string        `json:"invite,omitempty"`
+}
+
+// CreateInvite asks for an invite token to the current room. TTL is in
+// seconds; zero uses the server default. MaxUses zero means unlimited.
+type CreateInvite struct {
+	Header
+	TTL     int `json:"ttl,omitempty"`
+	MaxUses int `json:"max_uses,omitempty"`
+}
+
+type Rename struct {
+	Header
+	Name string `json:"name"`
+}
+
+// SetPrefs replaces the user's stored preferences.
+type SetPrefs struct {
+	Header
+	Prefs domain.UserPrefs `json:"prefs"`
+}
+
+// SDP carries an offer or an answer, in either direction.
+type SDP struct {
+	Header
+	SDP string `json:"sdp"`
+}
+
+// Candidate carries a trickled ICE candidate, in either direction.
+// An empty Candidate marks end-of-candidates.
+type Candidate struct {
+	Header
+	Candidate     string `json:"candidate"`
+	SDPMid        string `json:"sdpMid,omitempty"`
+	SDPMLineIndex uint16 `json:"sdpMLineIndex,omitempty"`
+}
+
+// Target names the member a moderator command acts on
+// (promote, demote, grant_floor, mute_member, transfer_ownership, unban).
+// Role is only read by promote/demote: when set, they change the room role
+// instead of the stage role.
+type Target struct {
+	Header
+	User domain.UserID   `json:"user"`
+	Role domain.RoomRole `json:"role,omitempty"`
+}
+
+// Kick removes a member; Reason is shown to them in kicked.
+type Kick struct {
+	Header
+	User   domain.UserID `json:"user"`
+	Reason string        `json:"reason,omitempty"`
+}
+
+// Ban keeps a user out of the room for Duration seconds, or for good when
+// it is zero. IP also bans the address the user is connected from.
+type Ban struct {
+	Header
+	User     domain.UserID `json:"user"`
+	Reason   string        `json:"reason,omitempty"`
+	Duration int           `json:"duration,omitempty"`
+	IP       bool          `json:"ip,omitempty"`
+}
+
+type WhisperStart struct {
+	Header
+	Users []domain.UserID `json:"users"`
+}
+
+// ChatSend posts a message; the server answers with ack and broadcasts
+// chat_message to the room.
+type ChatSend struct {
+	Header
+	Text string `json:"text"`
+}
+
+// ChatEdit changes a message; ChatDelete uses it without Text.
+type ChatEdit struct {
+	Header
+	Msg  domain.ChatMessageID `json:"msg"`
+	Text string               `json:"text,omitempty"`
+}
@@ -0,0 +1,76 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec encodes messages for one connection. The client picks a codec
+// through the WebSocket subprotocol; without one the connection uses JSON.
+type Codec interface {
+	// Subprotocol is the Sec-WebSocket-Protocol value that selects the codec.
+	Subprotocol() string
+	// Binary reports whether frames go out as binary WebSocket messages.
+	Binary() bool
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	JSON    Codec = jsonCodec{}
+	MsgPack Codec = msgpackCodec{}
+)
+
+// Codecs lists the supported codecs in server preference order.
+var Codecs = []Codec{MsgPack, JSON}
+
+// Subprotocols returns the subprotocol names to advertise on upgrade.
+func Subprotocols() []string {
+	out := make([]string, 0, len(Codecs))
+	for _, c := range Codecs {
+		out = append(out, c.Subprotocol())
+	}
+	return out
+}
+
+// CodecFor returns the codec negotiated for subprotocol, JSON if none matches.
+func CodecFor(subprotocol string) Codec {
+	for _, c := range Codecs {
+		if c.Subprotocol() == subprotocol {
+			return c
+		}
+	}
+	return JSON
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Subprotocol() string                { return "voice.v1.json" }
+func (jsonCodec) Binary() bool                       { return false }
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// msgpackCodec reuses the json struct tags so every message type works
+// with both encodings unchanged.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Subprotocol() string { return "voice.v1.msgpack" }
+func (msgpackCodec) Binary() bool        { return true }
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
@@ -0,0 +1,68 @@
+package protocol
+
+// ErrorCode is a stable, machine-readable error identifier.
+type ErrorCode string
+
+const (
+	ErrBadPayload         ErrorCode = "bad_payload"
+	ErrUnknownType        ErrorCode = "unknown_type"
+	ErrUnsupportedVersion ErrorCode = "unsupported_version"
+	ErrRateLimited        ErrorCode = "rate_limited"
+	ErrInternal           ErrorCode = "internal"
+
+	ErrAlreadyInRoom ErrorCode = "already_in_room"
+	ErrRoomNotFound  ErrorCode = "room_not_found"
+	ErrRoomFull      ErrorCode = "room_full"
+	ErrServerBusy    ErrorCode = "server_busy"
+	ErrNotInRoom     ErrorCode = "not_in_room"
+	ErrBadMode       ErrorCode = "bad_mode"
+	ErrInvalidName   ErrorCode = "invalid_name"
+	ErrForbidden     ErrorCode = "forbidden"
+	ErrNoSuchMember  ErrorCode = "no_such_member"
+	ErrBadRole       ErrorCode = "bad_role"
+	ErrAccessDenied  ErrorCode = "access_denied"
+
+	ErrBanned         ErrorCode = "banned"
+	ErrNotBanned      ErrorCode = "not_banned"
+	ErrBadPassword    ErrorCode = "bad_password"
+	ErrInviteRequired ErrorCode = "invite_required"
+	ErrInvalidInvite  ErrorCode = "invalid_invite"
+	ErrInviteExpired  ErrorCode = "invite_expired"
+	ErrInviteUsedUp   ErrorCode = "invite_used_up"
+
+	ErrNotStage       ErrorCode = "not_stage"
+	ErrAlreadySpeaker ErrorCode = "already_speaker"
+	ErrAudience       ErrorCode = "audience"
+	ErrNoFloorControl ErrorCode = "no_floor_control"
+	ErrNoTargets      ErrorCode = "no_targets"
+	ErrNotWhispering  ErrorCode = "not_whispering"
+
+	ErrInvalidMessage ErrorCode = "invalid_message"
+	ErrNoSuchMessage  ErrorCode = "no_such_message"
+
+	ErrNoSession    ErrorCode = "no_session"
+	ErrNoMedia      ErrorCode = "no_media"
+	ErrWebRTCFailed ErrorCode = "webrtc_failed"
+)
+
+// Error reports a failed command. The code sits in "error" so clients that
+// predate versioning keep working.
+type Error struct {
+	Header
+	Code    ErrorCode `json:"error"`
+	Message string    `json:"message,omitempty"`
+}
+
+func NewError(code ErrorCode, message string) *Error {
+	return &Error{Header: Header{Type: TypeError}, Code: code, Message: message}
+}
+
+// Ack completes a command that has no dedicated reply.
+type Ack struct {
+	Header
+	For Type `json:"for"`
+}
+
+func NewAck(t Type) *Ack {
+	return &Ack{Header: Header{Type: TypeAck}, For: t}
+}
@@ -0,0 +1,98 @@
+// Package protocol defines the signaling wire format: typed messages
+// exchanged over /api/ws/signal, the protocol version and error codes.
+package protocol
+
+// Version is the signaling protocol version spoken by the server.
+// Clients may send it as "v"; messages without "v" are treated as current.
+const Version = 1
+
+// Type is the "type" discriminator of every message.
+type Type string
+
+// Client → server commands.
+const (
+	TypeCreateRoom    Type = "create_room"
+	TypeJoin          Type = "join"
+	TypeLeave         Type = "leave"
+	TypePing          Type = "ping"
+	TypeRename        Type = "rename"
+	TypeWhoAmI        Type = "whoami"
+	TypeSetPrefs      Type = "set_prefs"
+	TypeOffer         Type = "offer"
+	TypeAnswer        Type = "answer"
+	TypeCandidate     Type = "candidate"
+	TypeDeafen        Type = "deafen"
+	TypeUndeafen      Type = "undeafen"
+	TypeMute          Type = "mute"
+	TypeUnmute        Type = "unmute"
+	TypeWhisperStart  Type = "whisper_start"
+	TypeWhisperStop   Type = "whisper_stop"
+	TypeRaiseHand     Type = "raise_hand"
+	TypeLowerHand     Type = "lower_hand"
+	TypePromote       Type = "promote"
+	TypeDemote        Type = "demote"
+	TypeRequestFloor  Type = "request_floor"
+	TypeReleaseFloor  Type = "release_floor"
+	TypeGrantFloor    Type = "grant_floor"
+	TypeRevokeFloor   Type = "revoke_floor"
+	TypeChatMessage   Type = "chat_message"
+	TypeChatEdit      Type = "chat_edit"
+	TypeChatDelete    Type = "chat_delete"
+	TypeListRooms     Type = "list_rooms"
+	TypeCreateInvite  Type = "create_invite"
+	TypeSubscribe     Type = "subscribe_rooms"
+	TypeUnsubscribe   Type = "unsubscribe_rooms"
+	TypeKick          Type = "kick"
+	TypeMuteMember    Type = "mute_member"
+	TypeUnmuteMember  Type = "unmute_member"
+	TypeTransferOwner Type = "transfer_ownership"
+	TypeCloseRoom     Type = "close_room"
+	TypeBan           Type = "ban"
+	TypeUnban         Type = "unban"
+	TypeListBans      Type = "list_bans"
+)
+
+// Server → client messages.
+const (
+	TypeHello          Type = "hello"
+	TypeAck            Type = "ack"
+	TypeError          Type = "error"
+	TypeRoomCreated    Type = "room_created"
+	TypeRoomState      Type = "room_state"
+	TypeLeft           Type = "left"
+	TypePong           Type = "pong"
+	TypeMemberJoined   Type = "member_joined"
+	TypeMemberLeft     Type = "member_left"
+	TypeMemberUpdated  Type = "member_updated"
+	TypeStageRole      Type = "stage_role"
+	TypeStageChanged   Type = "stage_changed"
+	TypeHandQueue      Type = "hand_queue"
+	TypeFloorChanged   Type = "floor_changed"
+	TypeWhisperStarted Type = "whisper_started"
+	TypeWhisperEnded   Type = "whisper_ended"
+	TypeWhisperUpdated Type = "whisper_updated"
+	TypeChatEdited     Type = "chat_edited"
+	TypeChatDeleted    Type = "chat_deleted"
+	TypeRooms          Type = "rooms"
+	TypeInvite         Type = "invite"
+	TypeRoomListed     Type = "room_listed"
+	TypeKicked         Type = "kicked"
+	TypeRoomClosed     Type = "room_closed"
+	TypeBans           Type = "bans"
+)
+
+// Header is embedded in every message. ID is chosen by the client for a
+// command and echoed by the server in the reply that completes it.
+type Header struct {
+	Type Type   `json:"type"`
+	ID   string `json:"id,omitempty"`
+	V    int    `json:"v,omitempty"`
+}
+
+// SetID stamps the message with the ID of the command it answers.
+func (h *Header) SetID(id string) { h.ID = id }
+
+// Reply is a server message that can answer a command.
+type Reply interface {
+	SetID(id string)
+}
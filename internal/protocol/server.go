@@ -0,0 +1,189 @@
+package protocol
+
+import (
+	"time"
+
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/domain"
+)
+
+// Hello is sent once when the connection opens. Resume is the token to
+// present on reconnect within ResumeTTL seconds; Resumed is set when this
+// connection took over an existing session.
+type Hello struct {
+	Header
+	Resume    string `json:"resume,omitempty"`
+	ResumeTTL int    `json:"resume_ttl,omitempty"`
+	Resumed   bool   `json:"resumed,omitempty"`
+}
+
+func NewHello(resume string, ttl time.Duration, resumed bool) *Hello {
+	return &Hello{
+		Header:    Header{Type: TypeHello, V: Version},
+		Resume:    resume,
+		ResumeTTL: int(ttl / time.Second),
+		Resumed:   resumed,
+	}
+}
+
+type RoomCreated struct {
+	Header
+	Room         domain.RoomID         `json:"room"`
+	Mode         domain.RoomMode       `json:"mode"`
+	Visibility   domain.RoomVisibility `json:"visibility"`
+	Locked       bool                  `json:"locked,omitempty"`
+	FloorControl bool                  `json:"floor_control,omitempty"`
+	MaxMembers   int                   `json:"max_members,omitempty"`
+	Persistent   bool                  `json:"persistent,omitempty"`
+}
+
+// Invite answers create_invite. ExpiresAt is a Unix timestamp.
+type Invite struct {
+	Header
+	Room      domain.RoomID `json:"room"`
+	Token     string        `json:"token"`
+	ExpiresAt int64         `json:"expires_at"`
+	MaxUses   int           `json:"max_uses,omitempty"`
+}
+
+// Rooms answers list_rooms and subscribe_rooms with the public rooms.
+type Rooms struct {
+	Header
+	Rooms []core.RoomInfo `json:"rooms"`
+}
+
+// RoomListed streams one public room change to lobby subscribers.
+type RoomListed struct {
+	Header
+	Room   core.RoomInfo       `json:"room"`
+	Change core.RoomListChange `json:"change"`
+}
+
+type RoomState struct {
+	Header
+	Room      domain.RoomID        `json:"room"`
+	RoomName  domain.RoomName      `json:"room_name"`
+	Mode      domain.RoomMode      `json:"mode"`
+	Members   []core.MemberDTO     `json:"members"`
+	Count     int                  `json:"count"`
+	HandQueue []core.MemberDTO     `json:"hand_queue,omitempty"`
+	Floor     *FloorChanged        `json:"floor,omitempty"`
+	Chat      []domain.ChatMessage `json:"chat,omitempty"`
+	// DelayMS is set for the echo test only.
+	DelayMS int64 `json:"delay_ms,omitempty"`
+}
+
+type WhoAmI struct {
+	Header
+	Username  string           `json:"username"`
+	Login     string           `json:"login,omitempty"`
+	Roles     []string         `json:"roles,omitempty"`
+	Prefs     domain.UserPrefs `json:"prefs"`
+	CreatedAt time.Time        `json:"created_at"`
+	Room      domain.RoomID    `json:"room,omitempty"`
+	RoomName  domain.RoomName  `json:"room_name,omitempty"`
+}
+
+// MemberEvent announces member_joined, member_left and member_updated.
+// On member_updated, Changes holds only the fields that changed, including
+// the username on a rename.
+type MemberEvent struct {
+	Header
+	User    domain.User       `json:"user"`
+	Changes *core.MemberPatch `json:"changes,omitempty"`
+}
+
+type StageRole struct {
+	Header
+	Role      domain.StageRole `json:"role"`
+	Direction string           `json:"direction"`
+}
+
+type StageChanged struct {
+	Header
+	User domain.User      `json:"user"`
+	Role domain.StageRole `json:"role"`
+}
+
+type HandQueue struct {
+	Header
+	Queue []core.MemberDTO `json:"queue"`
+}
+
+type FloorChanged struct {
+	Header
+	Holder *core.MemberDTO  `json:"holder"`
+	Queue  []core.MemberDTO `json:"queue"`
+	Reason core.FloorReason `json:"reason,omitempty"`
+}
+
+// Whisper announces whisper_started, whisper_updated and whisper_ended.
+type Whisper struct {
+	Header
+	From    core.MemberDTO   `json:"from"`
+	Targets []core.MemberDTO `json:"targets"`
+}
+
+// Chat carries a posted or edited message (chat_message, chat_edited).
+type Chat struct {
+	Header
+	Message domain.ChatMessage `json:"message"`
+}
+
+type ChatDeleted struct {
+	Header
+	Msg domain.ChatMessageID `json:"msg"`
+}
+
+// Left reasons.
+const (
+	// LeftOtherDevice: another device of the same user joined the room.
+	LeftOtherDevice = "other_device"
+)
+
+// Left tells a session it is no longer in its room without having asked.
+type Left struct {
+	Header
+	Reason string `json:"reason"`
+}
+
+func NewLeft(reason string) *Left {
+	return &Left{Header: Header{Type: TypeLeft}, Reason: reason}
+}
+
+// Kicked tells a session the server removed it from its room. Until is
+// set while a timed ban keeps it out.
+type Kicked struct {
+	Header
+	Room    domain.RoomID   `json:"room"`
+	Reason  core.KickReason `json:"reason"`
+	Message string          `json:"message,omitempty"`
+	Until   *time.Time      `json:"until,omitempty"`
+}
+
+// RoomClosed tells members, and lobby subscribers of a public room,
+// that the room is gone.
+type RoomClosed struct {
+	Header
+	Room   domain.RoomID    `json:"room"`
+	Reason core.CloseReason `json:"reason"`
+}
+
+// Bans answers list_bans.
+type Bans struct {
+	Header
+	Bans []domain.Ban `json:"bans"`
+}
+
+// CloseReplaced is the WebSocket close code sent to a device that was
+// replaced by a newer connection of the same client.
+const CloseReplaced = 4001
+
+// CloseServerBusy is sent instead of hello when the server is at its
+// session limit. It is the standard "try again later" code.
+const CloseServerBusy = 1013
+
+// Simple builds a message that carries nothing but its type (pong, left).
+func Simple(t Type) *Header {
+	return &Header{Type: t}
+}
@@ -0,0 +1,151 @@
+package voice
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	router "github.com/dkeye/Voice/internal/adapters/http"
+	signaling "github.com/dkeye/Voice/internal/adapters/signal"
+	"github.com/dkeye/Voice/internal/adapters/store"
+	"github.com/dkeye/Voice/internal/adapters/webhook"
+	"github.com/dkeye/Voice/internal/app"
+	"github.com/dkeye/Voice/internal/app/orch"
+	"github.com/dkeye/Voice/internal/app/sfu"
+	"github.com/dkeye/Voice/internal/config"
+	"github.com/dkeye/Voice/internal/core"
+)
+
+// NewServer wires the registry, rooms, SFU and signaling from cfg and
+// returns the HTTP handler serving the API, the WebSocket and the static
+// client. Commands are dispatched through handlers; pass DefaultHandlers()
+// unless you add your own. Background work stops when ctx is done.
+func NewServer(ctx context.Context, cfg *Config, handlers *Handlers) (*gin.Engine, error) {
+	manager, err := newRoomManager(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("open room store: %w", err)
+	}
+	// policy := app.SimplePolicy{}
+	var policy app.Policy
+	reg := app.NewRegistry()
+	if reg.Store, err = newUserStore(cfg); err != nil {
+		return nil, fmt.Errorf("open user store: %w", err)
+	}
+	relays := sfu.NewRelayManager()
+
+	limits := orch.Limits{
+		RoomMembers: cfg.RoomMaxMembers,
+		MaxSessions: cfg.MaxSessions,
+		MaxPeers:    cfg.MaxPeers,
+		MaxRelays:   cfg.MaxRelays,
+	}
+	o := orch.NewOrchestrator(reg, manager, policy, relays)
+	o.EchoDelay = cfg.EchoDelay
+	o.FloorMaxHold = cfg.FloorMaxHold
+	o.ChatHistory = cfg.ChatHistory
+	o.KickCooldown = cfg.KickCooldown
+	o.Limits = limits
+	o.RoomIdleTTL = cfg.RoomIdleTTL
+	o.RoomEmptyGrace = cfg.RoomEmptyGrace
+	o.UserTTL = cfg.UserTTL
+	o.GuestTTL = cfg.GuestTTL
+	o.AdminRole = cfg.AdminRole
+	if cfg.Secret == "" {
+		log.Warn().Msg("secret is empty, invite tokens will not survive a restart and logins will fail")
+	}
+	if o.Invites, err = newInviteBook(cfg); err != nil {
+		return nil, fmt.Errorf("open invite store: %w", err)
+	}
+	o.Events = signaling.NewNotifier(reg)
+	if cfg.AuthHookURL != "" {
+		o.Authorizer = webhook.NewAuthorizer(webhook.Config{
+			URL:      cfg.AuthHookURL,
+			Timeout:  cfg.AuthHookTimeout,
+			FailOpen: cfg.AuthHookFailOpen,
+			CacheTTL: cfg.AuthHookCacheTTL,
+		})
+	}
+
+	auth, err := newAuthenticator(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("set up authentication: %w", err)
+	}
+	go o.RunJanitor(ctx, cfg.JanitorInterval)
+	return router.SetupRouter(ctx, cfg, o, handlers, auth), nil
+}
+
+// newRoomManager picks the room storage backend named in the config.
+func newRoomManager(cfg *config.Config) (core.RoomManager, error) {
+	switch cfg.RoomStore {
+	case "file":
+		st, err := store.NewFileRoomStore(cfg.RoomStorePath)
+		if err != nil {
+			return nil, err
+		}
+		return app.NewPersistentRoomManager(st)
+	case "", "memory":
+		return app.NewRoomManager(), nil
+	default:
+		return nil, fmt.Errorf("unknown room_store %q", cfg.RoomStore)
+	}
+}
+
+// newInviteBook picks where invite use counts are kept.
+func newInviteBook(cfg *config.Config) (*app.InviteBook, error) {
+	switch cfg.InviteStore {
+	case "file":
+		st, err := store.NewFileInviteStore(cfg.InviteStorePath)
+		if err != nil {
+			return nil, err
+		}
+		return app.NewPersistentInviteBook([]byte(cfg.Secret), st)
+	case "", "memory":
+		return app.NewInviteBook([]byte(cfg.Secret)), nil
+	default:
+		return nil, fmt.Errorf("unknown invite_store %q", cfg.InviteStore)
+	}
+}
+
+// newAuthenticator picks the authentication scheme named in the config.
+func newAuthenticator(cfg *config.Config) (router.Authenticator, error) {
+	switch cfg.Auth {
+	case "", "cookie":
+		return router.CookieAuthenticator{AllowGuests: cfg.AllowGuests, MaxAge: cfg.GuestTTL}, nil
+	case "jwt":
+		a, err := router.NewJWTAuthenticator(router.JWTConfig{
+			Alg:        cfg.JWTAlg,
+			KeyFile:    cfg.JWTKeyFile,
+			Issuer:     cfg.JWTIssuer,
+			Audience:   cfg.JWTAudience,
+			UserClaim:  cfg.JWTUserClaim,
+			NameClaim:  cfg.JWTNameClaim,
+			RolesClaim: cfg.JWTRolesClaim,
+			Leeway:     cfg.JWTLeeway,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return a, nil
+	default:
+		return nil, fmt.Errorf("unknown auth %q", cfg.Auth)
+	}
+}
+
+// newUserStore picks the user profile and account backend named in the
+// config.
+func newUserStore(cfg *config.Config) (core.UserStore, error) {
+	switch cfg.UserStore {
+	case "file":
+		st, err := store.NewFileUserStore(cfg.UserStorePath)
+		if err != nil {
+			return nil, err
+		}
+		return st, nil
+	case "", "memory":
+		return store.NewMemoryUserStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown user_store %q", cfg.UserStore)
+	}
+}
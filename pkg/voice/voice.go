@@ -0,0 +1,83 @@
+// Package voice is the public surface of the server for embedding it in
+// another module: the signaling handler registry, the protocol types a
+// handler needs, and a constructor that wires the whole server.
+//
+// The types are aliases of the internal ones, so values move freely between
+// this package and the built-in handlers.
+package voice
+
+import (
+	router "github.com/dkeye/Voice/internal/adapters/http"
+	"github.com/dkeye/Voice/internal/adapters/signal"
+	"github.com/dkeye/Voice/internal/config"
+	"github.com/dkeye/Voice/internal/core"
+	"github.com/dkeye/Voice/internal/protocol"
+)
+
+type (
+	// Config is the server configuration; see LoadConfig.
+	Config = config.Config
+
+	// Handlers maps message types to handlers and runs middleware around them.
+	Handlers = signal.Handlers
+	// HandlerFunc processes one command type. It must complete the command
+	// with exactly one Reply, Ack or Fail on the controller.
+	HandlerFunc = signal.HandlerFunc
+	// Middleware wraps a handler.
+	Middleware = signal.Middleware
+	// Request is one decoded client command together with its origin.
+	Request = signal.Request
+	// Controller is the signaling controller passed to every handler.
+	Controller = signal.SignalWSController
+
+	// Authenticator resolves the identity of an HTTP or WebSocket request.
+	Authenticator = router.Authenticator
+	// Identity is what an Authenticator resolves a request to.
+	Identity = core.Identity
+	// SessionID identifies one signaling connection.
+	SessionID = core.SessionID
+
+	// MessageType is the "type" field of a protocol message.
+	MessageType = protocol.Type
+	// Header is embedded in every message; embed it in custom replies.
+	Header = protocol.Header
+	// Reply is a server message that can answer a command.
+	Reply = protocol.Reply
+	// ErrorCode is a stable, machine-readable error identifier.
+	ErrorCode = protocol.ErrorCode
+)
+
+// Error codes a custom handler is most likely to fail with.
+const (
+	ErrBadPayload  = protocol.ErrBadPayload
+	ErrUnknownType = protocol.ErrUnknownType
+	ErrRateLimited = protocol.ErrRateLimited
+	ErrInternal    = protocol.ErrInternal
+	ErrForbidden   = protocol.ErrForbidden
+	ErrNotInRoom   = protocol.ErrNotInRoom
+	ErrNoSession   = protocol.ErrNoSession
+)
+
+var (
+	// LoadConfig reads the configuration from the config file and environment.
+	LoadConfig = config.Load
+
+	// NewHandlers returns an empty registry.
+	NewHandlers = signal.NewHandlers
+	// DefaultHandlers returns a registry with every built-in command plus
+	// panic recovery and debug logging.
+	DefaultHandlers = signal.DefaultHandlers
+	// RegisterBuiltins installs the handlers for every built-in command.
+	RegisterBuiltins = signal.RegisterBuiltins
+
+	// Recover turns a panicking handler into an "internal" error.
+	Recover = signal.Recover
+	// Logging logs every command with its duration at debug level.
+	Logging = signal.Logging
+	// Observe calls fn after every command; use it to feed metrics.
+	Observe = signal.Observe
+	// RateLimit allows each user at most limit commands per interval.
+	RateLimit = signal.RateLimit
+	// Require runs the handler only if allow accepts the request.
+	Require = signal.Require
+)
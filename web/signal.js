@@ -5,6 +5,7 @@ import { NetStats } from './net-stats.js';
 
 let ws = null;
 let wsState = 'idle'; // idle | connecting | open | closed
+let resumeToken = ''; // из hello; позволяет переподключиться без выхода из комнаты
 const listeners = new Map(); // type -> Set<fn>
 
 function emit(type, payload) {
@@ -40,7 +41,10 @@ export function connect() {
     }
 
     const proto = location.protocol === 'https:' ? 'wss:' : 'ws:';
-    const url = `${proto}//${location.host}/api/ws/signal`;
+    let url = `${proto}//${location.host}/api/ws/signal`;
+    if (resumeToken) {
+        url += `?resume=${encodeURIComponent(resumeToken)}`;
+    }
 
     wsState = 'connecting';
     emit('ws_state', wsState);
@@ -79,6 +83,9 @@ export function connect() {
             return;
         }
         const t = msg?.type || 'unknown';
+        if (t === 'hello') {
+            resumeToken = msg.resume || '';
+        }
         emit(t, msg);
     };
 }
@@ -286,15 +286,32 @@ onSignal('member_updated', (msg) => {
     const u = normalizeMember(msg.user);
     currentMembers = currentMembers.map((m) => {
         const mm = normalizeMember(m);
-        return mm.id === u.id ? msg.user : m;
+        return mm.id === u.id ? { ...m, ...msg.user, ...(msg.changes || {}) } : m;
     });
     renderMembers(currentMembers);
     log(`MEMBER UPDATED: ${u.username}`);
 });
 
 // выход
-onSignal('left', () => {
-    log('LEFT room');
+onSignal('left', (msg) => {
+    log(msg.reason ? `LEFT room: ${msg.reason}` : 'LEFT room');
+    resetRoom();
+});
+
+// удалён модератором или сервером
+onSignal('kicked', (msg) => {
+    const until = msg.until ? ` until ${new Date(msg.until).toLocaleString()}` : '';
+    log(`KICKED: ${msg.reason}${msg.message ? ` (${msg.message})` : ''}${until}`);
+    resetRoom();
+});
+
+// комната закрыта владельцем или по таймауту
+onSignal('room_closed', (msg) => {
+    log(`ROOM CLOSED: ${msg.reason}`);
+    if (inRoom && msg.room === currentRoomId) resetRoom();
+});
+
+function resetRoom() {
     inRoom = false;
     currentRoomId = '';
     currentRoomName = '';
@@ -307,7 +324,7 @@ onSignal('left', () => {
         stopVoice();
         voiceActive = false;
     }
-});
+}
 
 // ошибки
 onSignal('error', (msg) => {